@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v2"
 )
 
@@ -11,30 +13,272 @@ type Config struct {
 		Level   string `yaml:"level"`
 		Verbose bool   `yaml:"verbose"`
 	} `yaml:"logging"`
-	Namespace     string `yaml:"namespace"`
-	CheckInterval int    `yaml:"check_interval"`
+	Namespace string `yaml:"namespace"`
+	// Namespaces lists the Velero installations to watch; "*" watches every
+	// namespace holding a BackupStorageLocation. It defaults to Namespace.
+	Namespaces    []string `yaml:"namespaces"`
+	CheckInterval int      `yaml:"check_interval"`
+	// ClusterName identifies the cluster the controller runs in. When it is
+	// empty, ClusterDetection can read it from the cluster.
+	ClusterName      string           `yaml:"cluster_name"`
+	ClusterDetection ClusterDetection `yaml:"cluster_detection"`
+	// Clusters lists the clusters watched from this deployment. Empty
+	// watches the cluster it runs in.
+	Clusters []Cluster `yaml:"clusters"`
+	// Reload watches the config file and applies valid changes without a
+	// restart.
+	Reload struct {
+		Enabled bool `yaml:"enabled"`
+		// Interval is expressed in seconds.
+		Interval        int  `yaml:"interval"`
+		NotifyOnFailure bool `yaml:"notify_on_failure"`
+	} `yaml:"reload"`
+	// Filters limits the backups and schedules that are reported.
+	Filters struct {
+		LabelSelector string     `yaml:"label_selector"`
+		Backups       NameFilter `yaml:"backups"`
+		Schedules     NameFilter `yaml:"schedules"`
+		OnlyScheduled bool       `yaml:"only_scheduled"`
+	} `yaml:"filters"`
+	// Server exposes /metrics and the silences API. An empty
+	// ListenAddress disables it, and the silences API is only served with
+	// an APIToken, read from APITokenFile or the environment.
+	Server struct {
+		ListenAddress string `yaml:"listen_address"`
+		APIToken      string `yaml:"api_token"`
+		APITokenFile  string `yaml:"api_token_file"`
+	} `yaml:"server"`
+	Monitoring struct {
+		MissedSchedules struct {
+			Enabled bool `yaml:"enabled"`
+			// GracePeriod is expressed in seconds.
+			GracePeriod int `yaml:"grace_period"`
+		} `yaml:"missed_schedules"`
+		StuckBackups struct {
+			Enabled bool `yaml:"enabled"`
+			// MaxDuration and the per-schedule overrides are expressed
+			// in seconds.
+			MaxDuration int            `yaml:"max_duration"`
+			Schedules   map[string]int `yaml:"schedules"`
+		} `yaml:"stuck_backups"`
+		Regressions struct {
+			Enabled       bool    `yaml:"enabled"`
+			Window        int     `yaml:"window"`
+			MinSamples    int     `yaml:"min_samples"`
+			DurationRatio float64 `yaml:"duration_ratio"`
+			ItemsRatio    float64 `yaml:"items_ratio"`
+		} `yaml:"regressions"`
+		StorageLocations struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"storage_locations"`
+		Snapshots struct {
+			Enabled       bool `yaml:"enabled"`
+			VolumeDetails bool `yaml:"volume_details"`
+			// Timeout is expressed in seconds.
+			Timeout int `yaml:"timeout"`
+		} `yaml:"snapshots"`
+		Deletions struct {
+			Enabled bool `yaml:"enabled"`
+			// ExpiryWarningDays is how many days before expiration the
+			// backups matching RetentionSelector get a heads-up; 0 disables it.
+			ExpiryWarningDays int `yaml:"expiry_warning_days"`
+			// RetentionSelector is a Kubernetes label selector.
+			RetentionSelector string `yaml:"retention_selector"`
+		} `yaml:"deletions"`
+		Recoveries struct {
+			Enabled     bool `yaml:"enabled"`
+			MinFailures int  `yaml:"min_failures"`
+		} `yaml:"recoveries"`
+		APIHealth struct {
+			// FailureThreshold is the number of consecutive failed checks
+			// before the "lost contact" alert is sent.
+			FailureThreshold int `yaml:"failure_threshold"`
+		} `yaml:"api_health"`
+	} `yaml:"monitoring"`
 	Notifications struct {
-		NotificationPrefix string `yaml:"notification_prefix"`
-		Slack              struct {
-			Enabled      bool   `yaml:"enabled"`
-			FailuresOnly bool   `yaml:"failures_only"`
-			Webhook      string `yaml:"webhook_url"`
-			Channel      string `yaml:"channel"`
-			Username     string `yaml:"username"`
-		} `yaml:"slack"`
-		Email struct {
-			Enabled      bool   `yaml:"enabled"`
-			FailuresOnly bool   `yaml:"failures_only"`
-			SMTPServer   string `yaml:"smtp_server"`
-			SMTPPort     int    `yaml:"smtp_port"`
-			Username     string `yaml:"username"`
-			Password     string `yaml:"password"`
-			From         string `yaml:"from"`
-			To           string `yaml:"to"`
-		} `yaml:"email"`
+		NotificationPrefix string      `yaml:"notification_prefix"`
+		Route              Route       `yaml:"route"`
+		Slack              SlackConfig `yaml:"slack"`
+		Email              EmailConfig `yaml:"email"`
+		// Receivers lists additional named notifiers. The slack and email
+		// blocks above are appended to it when enabled.
+		Receivers  []Receiver `yaml:"receivers"`
+		BackupLogs struct {
+			Enabled bool `yaml:"enabled"`
+			// MaxAttachmentSize is expressed in bytes.
+			MaxAttachmentSize int `yaml:"max_attachment_size"`
+			MaxErrorLines     int `yaml:"max_error_lines"`
+			// Timeout is expressed in seconds.
+			Timeout int `yaml:"timeout"`
+		} `yaml:"backup_logs"`
+		BackupResults struct {
+			Enabled bool `yaml:"enabled"`
+			// Timeout is expressed in seconds.
+			Timeout int `yaml:"timeout"`
+		} `yaml:"backup_results"`
+		VolumeBackups struct {
+			Enabled bool `yaml:"enabled"`
+		} `yaml:"volume_backups"`
+		Digests []Digest `yaml:"digests"`
+		// Throttle applies to every receiver that does not set its own.
+		Throttle Throttle `yaml:"throttle"`
+		Silences Silences `yaml:"silences"`
 	} `yaml:"notifications"`
 }
 
+// Receiver is a named notifier. Exactly one of Slack or Email must be set;
+// filters such as failures_only live in that block.
+type Receiver struct {
+	Name     string       `yaml:"name"`
+	Slack    *SlackConfig `yaml:"slack"`
+	Email    *EmailConfig `yaml:"email"`
+	Throttle *Throttle    `yaml:"throttle"`
+}
+
+// Throttle deduplicates similar events and rate limits a receiver with a
+// token bucket.
+type Throttle struct {
+	// DedupWindow is expressed in seconds; 0 disables deduplication.
+	DedupWindow int `yaml:"dedup_window"`
+	// RatePerMinute is the sustained number of notifications per minute;
+	// 0 disables rate limiting.
+	RatePerMinute float64 `yaml:"rate_per_minute"`
+	Burst         int     `yaml:"burst"`
+}
+
+// Digest is a periodic summary of the finished backups, sent on a cron
+// schedule to the listed receivers, or routed like other events when the
+// list is empty.
+type Digest struct {
+	Name      string   `yaml:"name"`
+	Schedule  string   `yaml:"schedule"`
+	Receivers []string `yaml:"receivers"`
+}
+
+type SlackConfig struct {
+	// Name identifies the receiver in routes. Defaults to "slack".
+	Name         string `yaml:"name"`
+	Enabled      bool   `yaml:"enabled"`
+	FailuresOnly bool   `yaml:"failures_only"`
+	Webhook      string `yaml:"webhook_url"`
+	// WebhookFile and WebhookSecret read the webhook URL from a file or a
+	// Kubernetes Secret instead.
+	WebhookFile   string        `yaml:"webhook_url_file"`
+	WebhookSecret *SecretKeyRef `yaml:"webhook_url_secret"`
+	Channel       string        `yaml:"channel"`
+	Username      string        `yaml:"username"`
+}
+
+type EmailConfig struct {
+	// Name identifies the receiver in routes. Defaults to "email".
+	Name         string `yaml:"name"`
+	Enabled      bool   `yaml:"enabled"`
+	FailuresOnly bool   `yaml:"failures_only"`
+	SMTPServer   string `yaml:"smtp_server"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	// PasswordFile and PasswordSecret read the password from a file or a
+	// Kubernetes Secret instead.
+	PasswordFile   string        `yaml:"password_file"`
+	PasswordSecret *SecretKeyRef `yaml:"password_secret"`
+	From           string        `yaml:"from"`
+	To             string        `yaml:"to"`
+	// TLSMode is one of none, opportunistic, starttls or implicit.
+	TLSMode            string `yaml:"tls_mode"`
+	AuthMechanism      string `yaml:"auth_mechanism"`
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	// DialTimeout is expressed in seconds.
+	DialTimeout int `yaml:"dial_timeout"`
+}
+
+// Route is a node of the notification routing tree. The top-level route is
+// the default route; child routes narrow it down by schedule, backup labels,
+// included namespaces, storage location or phase.
+type Route struct {
+	Receiver  string   `yaml:"receiver"`
+	Receivers []string `yaml:"receivers"`
+	Matchers  `yaml:",inline"`
+	Continue  bool    `yaml:"continue"`
+	Routes    []Route `yaml:"routes"`
+	// Mentions are added to the chat messages of the route, such as
+	// "<!here>" or "<!subteam^S123>" in Slack.
+	Mentions []string `yaml:"mentions"`
+}
+
+// Cluster is a cluster watched from a central management cluster, reached
+// through a context of the kubeconfig file or a kubeconfig Secret. A cluster
+// with neither is the one the controller connects to.
+type Cluster struct {
+	// Name identifies the cluster in notifications and routes.
+	Name             string        `yaml:"name"`
+	Context          string        `yaml:"context"`
+	KubeconfigSecret *SecretKeyRef `yaml:"kubeconfig_secret"`
+}
+
+// NameFilter lists include and exclude patterns for object names: globs
+// such as "test-*", or regular expressions wrapped in slashes.
+type NameFilter struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// ClusterDetection reads the cluster name from the UID of the kube-system
+// namespace (kube-system-uid), a label of the nodes (node-label) or a key of
+// a ConfigMap (configmap).
+type ClusterDetection struct {
+	Source    string `yaml:"source"`
+	NodeLabel string `yaml:"node_label"`
+	ConfigMap struct {
+		Namespace string `yaml:"namespace"`
+		Name      string `yaml:"name"`
+		Key       string `yaml:"key"`
+	} `yaml:"configmap"`
+}
+
+// SecretKeyRef points to a key of a Secret. The namespace defaults to the
+// Velero namespace.
+type SecretKeyRef struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	Key       string `yaml:"key"`
+}
+
+// Matchers select events by schedule, backup labels, included namespaces,
+// storage location or phase. Values accept shell-style globs.
+type Matchers struct {
+	Schedules        []string          `yaml:"schedules"`
+	Labels           map[string]string `yaml:"labels"`
+	Namespaces       []string          `yaml:"namespaces"`
+	StorageLocations []string          `yaml:"storage_locations"`
+	Phases           []string          `yaml:"phases"`
+	VeleroNamespaces []string          `yaml:"velero_namespaces"`
+	Clusters         []string          `yaml:"clusters"`
+}
+
+// Silences configures the muting of notifications. Silences can also be
+// created through the HTTP API and the velero-notifications/silence
+// annotation on Schedules.
+type Silences struct {
+	// ConfigMap names a ConfigMap in the Velero namespace whose
+	// silences.yaml key lists silences.
+	ConfigMap string `yaml:"configmap"`
+	// SyncInterval is expressed in seconds and paces the reads of the
+	// ConfigMap and of the Schedule annotations.
+	SyncInterval       int                 `yaml:"sync_interval"`
+	MaintenanceWindows []MaintenanceWindow `yaml:"maintenance_windows"`
+}
+
+// MaintenanceWindow mutes the matching events for Duration seconds every
+// time its cron Schedule fires.
+type MaintenanceWindow struct {
+	Name     string `yaml:"name"`
+	Schedule string `yaml:"schedule"`
+	Duration int    `yaml:"duration"`
+	Matchers `yaml:",inline"`
+}
+
 func LoadConfig(path string) (*Config, error) {
 
 	data, err := os.ReadFile(path)
@@ -43,15 +287,257 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes a configuration, expands the environment variables
+// in its string values, then defaults and validates it. Secrets stored in
+// files are read; Kubernetes Secret references are left to ResolveSecrets.
+func ParseConfig(data []byte) (*Config, error) {
 	var cfg Config
 
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 
+	if err := expandEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Namespaces) == 0 {
+		cfg.Namespaces = []string{cfg.Namespace}
+	}
+
 	if cfg.CheckInterval < 2 {
 		cfg.CheckInterval = 2
 	}
 
+	if cfg.Reload.Interval <= 0 {
+		cfg.Reload.Interval = 10
+	}
+
+	if cfg.Notifications.Silences.SyncInterval <= 0 {
+		cfg.Notifications.Silences.SyncInterval = 60
+	}
+
+	if cfg.Monitoring.MissedSchedules.GracePeriod <= 0 {
+		cfg.Monitoring.MissedSchedules.GracePeriod = 3600
+	}
+
+	if cfg.Monitoring.StuckBackups.MaxDuration <= 0 {
+		cfg.Monitoring.StuckBackups.MaxDuration = 4 * 3600
+	}
+
+	if cfg.Monitoring.Regressions.Window <= 0 {
+		cfg.Monitoring.Regressions.Window = 10
+	}
+
+	if cfg.Monitoring.Regressions.MinSamples <= 0 {
+		cfg.Monitoring.Regressions.MinSamples = 3
+	}
+
+	if cfg.Monitoring.Regressions.DurationRatio <= 0 {
+		cfg.Monitoring.Regressions.DurationRatio = 3
+	}
+
+	if cfg.Monitoring.Regressions.ItemsRatio <= 0 {
+		cfg.Monitoring.Regressions.ItemsRatio = 0.5
+	}
+
+	if cfg.Monitoring.APIHealth.FailureThreshold <= 0 {
+		cfg.Monitoring.APIHealth.FailureThreshold = 3
+	}
+
+	if cfg.Monitoring.Recoveries.MinFailures <= 0 {
+		cfg.Monitoring.Recoveries.MinFailures = 1
+	}
+
+	if cfg.Monitoring.Snapshots.Timeout <= 0 {
+		cfg.Monitoring.Snapshots.Timeout = 60
+	}
+
+	if cfg.Notifications.BackupLogs.MaxAttachmentSize <= 0 {
+		cfg.Notifications.BackupLogs.MaxAttachmentSize = 1024 * 1024
+	}
+
+	if cfg.Notifications.BackupLogs.MaxErrorLines <= 0 {
+		cfg.Notifications.BackupLogs.MaxErrorLines = 10
+	}
+
+	if cfg.Notifications.BackupLogs.Timeout <= 0 {
+		cfg.Notifications.BackupLogs.Timeout = 60
+	}
+
+	if cfg.Notifications.BackupResults.Timeout <= 0 {
+		cfg.Notifications.BackupResults.Timeout = 60
+	}
+
+	if err := normalizeReceivers(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := readSecretFiles(cfg.Notifications.Receivers, cfg.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := readAPIToken(&cfg.Server.APIToken, cfg.Server.APITokenFile); err != nil {
+		return nil, err
+	}
+
+	if err := validateDigests(cfg.Notifications.Digests); err != nil {
+		return nil, err
+	}
+
+	if err := validateMaintenanceWindows(cfg.Notifications.Silences.MaintenanceWindows); err != nil {
+		return nil, err
+	}
+
+	if err := validateClusters(cfg.Clusters, cfg.Namespace); err != nil {
+		return nil, err
+	}
+
+	if err := validateClusterDetection(&cfg.ClusterDetection); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+// normalizeReceivers appends the enabled legacy slack and email blocks to the
+// receivers list and checks that receiver names are unique.
+func normalizeReceivers(cfg *Config) error {
+	// The receiver list names and enables its entries itself; the name and
+	// enabled fields only apply to the top-level slack and email blocks.
+	for _, receiver := range cfg.Notifications.Receivers {
+		if slack := receiver.Slack; slack != nil && (slack.Name != "" || slack.Enabled) {
+			return fmt.Errorf("receivers: receiver %q sets name or enabled inside slack", receiver.Name)
+		}
+		if email := receiver.Email; email != nil && (email.Name != "" || email.Enabled) {
+			return fmt.Errorf("receivers: receiver %q sets name or enabled inside email", receiver.Name)
+		}
+	}
+
+	if slack := cfg.Notifications.Slack; slack.Enabled {
+		if slack.Name == "" {
+			slack.Name = "slack"
+		}
+		cfg.Notifications.Receivers = append(cfg.Notifications.Receivers, Receiver{Name: slack.Name, Slack: &slack})
+	}
+
+	if email := cfg.Notifications.Email; email.Enabled {
+		if email.Name == "" {
+			email.Name = "email"
+		}
+		cfg.Notifications.Receivers = append(cfg.Notifications.Receivers, Receiver{Name: email.Name, Email: &email})
+	}
+
+	seen := make(map[string]bool, len(cfg.Notifications.Receivers))
+	for _, receiver := range cfg.Notifications.Receivers {
+		if receiver.Name == "" {
+			return fmt.Errorf("receivers: every receiver needs a name")
+		}
+
+		if seen[receiver.Name] {
+			return fmt.Errorf("receivers: duplicate receiver name %q", receiver.Name)
+		}
+		seen[receiver.Name] = true
+
+		if (receiver.Slack == nil) == (receiver.Email == nil) {
+			return fmt.Errorf("receivers: receiver %q must define exactly one of slack or email", receiver.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateDigests(digests []Digest) error {
+	seen := make(map[string]bool, len(digests))
+	for _, digest := range digests {
+		if digest.Name == "" {
+			return fmt.Errorf("digests: every digest needs a name")
+		}
+
+		if seen[digest.Name] {
+			return fmt.Errorf("digests: duplicate digest name %q", digest.Name)
+		}
+		seen[digest.Name] = true
+
+		if _, err := cron.ParseStandard(digest.Schedule); err != nil {
+			return fmt.Errorf("digests: invalid schedule %q for digest %q: %w", digest.Schedule, digest.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateMaintenanceWindows(windows []MaintenanceWindow) error {
+	for _, window := range windows {
+		if window.Name == "" {
+			return fmt.Errorf("maintenance_windows: every window needs a name")
+		}
+
+		if _, err := cron.ParseStandard(window.Schedule); err != nil {
+			return fmt.Errorf("maintenance_windows: invalid schedule %q for window %q: %w", window.Schedule, window.Name, err)
+		}
+
+		if window.Duration <= 0 {
+			return fmt.Errorf("maintenance_windows: window %q needs a positive duration", window.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateClusters checks that clusters have unique names and at most one
+// connection method, and defaults the Secret namespace.
+func validateClusters(clusters []Cluster, namespace string) error {
+	seen := make(map[string]bool, len(clusters))
+	for i := range clusters {
+		cluster := &clusters[i]
+		if cluster.Name == "" {
+			return fmt.Errorf("clusters: every cluster needs a name")
+		}
+		if seen[cluster.Name] {
+			return fmt.Errorf("clusters: duplicate cluster name %q", cluster.Name)
+		}
+		seen[cluster.Name] = true
+
+		if secret := cluster.KubeconfigSecret; secret != nil {
+			if cluster.Context != "" {
+				return fmt.Errorf("clusters: cluster %q sets both context and kubeconfig_secret", cluster.Name)
+			}
+			if secret.Name == "" {
+				return fmt.Errorf("clusters: kubeconfig_secret of cluster %q needs a name", cluster.Name)
+			}
+			if secret.Namespace == "" {
+				secret.Namespace = namespace
+			}
+		}
+	}
+	return nil
+}
+
+// validateClusterDetection checks the settings of the detection source and
+// defaults the ConfigMap namespace and key.
+func validateClusterDetection(detection *ClusterDetection) error {
+	switch detection.Source {
+	case "", "kube-system-uid":
+	case "node-label":
+		if detection.NodeLabel == "" {
+			return fmt.Errorf("cluster_detection: node-label needs node_label")
+		}
+	case "configmap":
+		if detection.ConfigMap.Name == "" {
+			return fmt.Errorf("cluster_detection: configmap needs configmap.name")
+		}
+		if detection.ConfigMap.Namespace == "" {
+			detection.ConfigMap.Namespace = "kube-system"
+		}
+		if detection.ConfigMap.Key == "" {
+			detection.ConfigMap.Key = "cluster-name"
+		}
+	default:
+		return fmt.Errorf("cluster_detection: unknown source %q, expected kube-system-uid, node-label or configmap", detection.Source)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigMergesLegacyBlocksIntoReceivers(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, `
+notifications:
+  slack:
+    enabled: true
+    webhook_url: "https://hooks.slack.com/services/T0/B0/XX"
+  email:
+    enabled: false
+  receivers:
+    - name: team-a
+      slack:
+        webhook_url: "https://hooks.slack.com/services/T0/B1/XX"
+    - name: dba
+      email:
+        smtp_server: "smtp.example.com"
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	names := make([]string, 0, len(cfg.Notifications.Receivers))
+	for _, receiver := range cfg.Notifications.Receivers {
+		names = append(names, receiver.Name)
+	}
+
+	want := []string{"team-a", "dba", "slack"}
+	if len(names) != len(want) {
+		t.Fatalf("expected receivers %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected receivers %v, got %v", want, names)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidReceivers(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"duplicate name": `
+notifications:
+  receivers:
+    - name: team-a
+      slack: {webhook_url: "https://hooks.slack.com/a"}
+    - name: team-a
+      slack: {webhook_url: "https://hooks.slack.com/b"}
+`,
+		"missing type": `
+notifications:
+  receivers:
+    - name: team-a
+`,
+		"both types": `
+notifications:
+  receivers:
+    - name: team-a
+      slack: {webhook_url: "https://hooks.slack.com/a"}
+      email: {smtp_server: "smtp.example.com"}
+`,
+		"enabled inside slack": `
+notifications:
+  receivers:
+    - name: team-a
+      slack: {enabled: true, webhook_url: "https://hooks.slack.com/a"}
+`,
+		"name inside email": `
+notifications:
+  receivers:
+    - name: dba
+      email: {name: dba-email, smtp_server: "smtp.example.com"}
+`,
+	}
+
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidDigests(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"missing name": `
+notifications:
+  digests:
+    - schedule: "0 8 * * *"
+`,
+		"duplicate name": `
+notifications:
+  digests:
+    - {name: daily, schedule: "0 8 * * *"}
+    - {name: daily, schedule: "0 9 * * *"}
+`,
+		"bad schedule": `
+notifications:
+  digests:
+    - {name: daily, schedule: "every morning"}
+`,
+	}
+
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadConfigRejectsInvalidMaintenanceWindows(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"missing name": `
+notifications:
+  silences:
+    maintenance_windows:
+      - {schedule: "0 22 * * 6", duration: 3600}
+`,
+		"bad schedule": `
+notifications:
+  silences:
+    maintenance_windows:
+      - {name: migration, schedule: "saturday night", duration: 3600}
+`,
+		"missing duration": `
+notifications:
+  silences:
+    maintenance_windows:
+      - {name: migration, schedule: "0 22 * * 6"}
+`,
+	}
+
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadConfigValidatesClusters(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"missing name": `
+clusters:
+  - context: prod
+`,
+		"duplicate name": `
+clusters:
+  - {name: prod, context: prod}
+  - {name: prod, context: prod-2}
+`,
+		"context and secret": `
+clusters:
+  - name: prod
+    context: prod
+    kubeconfig_secret: {name: prod-kubeconfig}
+`,
+	}
+
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	cfg, err := LoadConfig(writeConfig(t, `
+namespace: velero
+clusters:
+  - name: prod
+    kubeconfig_secret: {name: prod-kubeconfig}
+`))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if secret := cfg.Clusters[0].KubeconfigSecret; secret.Namespace != "velero" {
+		t.Fatalf("expected the secret namespace to default to the Velero namespace, got %q", secret.Namespace)
+	}
+}
+
+func TestLoadConfigValidatesClusterDetection(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unknown source":     "cluster_detection: {source: dns}\n",
+		"missing node label": "cluster_detection: {source: node-label}\n",
+		"missing configmap":  "cluster_detection: {source: configmap}\n",
+	}
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	cfg, err := LoadConfig(writeConfig(t, "cluster_detection: {source: configmap, configmap: {name: cluster-identity}}\n"))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if configMap := cfg.ClusterDetection.ConfigMap; configMap.Namespace != "kube-system" || configMap.Key != "cluster-name" {
+		t.Fatalf("expected ConfigMap defaults, got %+v", configMap)
+	}
+}
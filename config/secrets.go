@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// envReference matches ${NAME}. The bare $NAME form is not expanded, so
+// dollar signs elsewhere in the file are kept.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces the ${NAME} references in the string values of a
+// decoded configuration with the environment, so values are never parsed as
+// YAML and comments are never expanded. An unset variable is an error
+// rather than an empty password or webhook.
+func expandEnv(cfg *Config) error {
+	var missing []string
+	expandStrings(reflect.ValueOf(cfg).Elem(), &missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("config references unset environment variables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func expandStrings(v reflect.Value, missing *[]string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(envReference.ReplaceAllStringFunc(v.String(), func(reference string) string {
+			name := envReference.FindStringSubmatch(reference)[1]
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				*missing = append(*missing, name)
+				return reference
+			}
+			return value
+		}))
+	case reflect.Pointer:
+		if !v.IsNil() {
+			expandStrings(v.Elem(), missing)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				expandStrings(v.Field(i), missing)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandStrings(v.Index(i), missing)
+		}
+	case reflect.Map:
+		// Map values are not addressable, so each is expanded in a copy.
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			expandStrings(value, missing)
+			v.SetMapIndex(key, value)
+		}
+	}
+}
+
+// readSecretFiles checks that every secret field has at most one source,
+// reads the ones stored in files and defaults the namespace of the Secret
+// references.
+func readSecretFiles(receivers []Receiver, namespace string) error {
+	for _, receiver := range receivers {
+		if slack := receiver.Slack; slack != nil {
+			if err := readSecretFile(receiver.Name, "webhook_url", &slack.Webhook, slack.WebhookFile, slack.WebhookSecret, namespace); err != nil {
+				return err
+			}
+		}
+		if email := receiver.Email; email != nil {
+			if err := readSecretFile(receiver.Name, "password", &email.Password, email.PasswordFile, email.PasswordSecret, namespace); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSecretFile(receiver, field string, value *string, file string, secret *SecretKeyRef, namespace string) error {
+	sources := 0
+	for _, set := range []bool{*value != "", file != "", secret != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("receivers: receiver %q sets more than one of %s, %s_file and %s_secret", receiver, field, field, field)
+	}
+
+	if secret != nil {
+		if secret.Name == "" || secret.Key == "" {
+			return fmt.Errorf("receivers: receiver %q %s_secret needs a name and a key", receiver, field)
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = namespace
+		}
+	}
+
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("receivers: receiver %q %s_file: %w", receiver, field, err)
+	}
+	*value = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+// readAPIToken reads the token of the silences API from file, if set.
+func readAPIToken(token *string, file string) error {
+	if file == "" {
+		return nil
+	}
+	if *token != "" {
+		return fmt.Errorf("server: set only one of api_token and api_token_file")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("server: api_token_file: %w", err)
+	}
+	*token = strings.TrimRight(string(data), "\r\n")
+	return nil
+}
+
+// ResolveSecrets reads the secret fields that reference a Kubernetes Secret
+// with lookup.
+func (c *Config) ResolveSecrets(lookup func(SecretKeyRef) (string, error)) error {
+	for _, receiver := range c.Notifications.Receivers {
+		if slack := receiver.Slack; slack != nil && slack.WebhookSecret != nil {
+			value, err := lookup(*slack.WebhookSecret)
+			if err != nil {
+				return fmt.Errorf("receiver %s webhook_url_secret: %w", receiver.Name, err)
+			}
+			slack.Webhook = value
+		}
+		if email := receiver.Email; email != nil && email.PasswordSecret != nil {
+			value, err := lookup(*email.PasswordSecret)
+			if err != nil {
+				return fmt.Errorf("receiver %s password_secret: %w", receiver.Name, err)
+			}
+			email.Password = value
+		}
+	}
+	return nil
+}
+
+// Secrets returns the values of the secret fields, to be kept out of the
+// logs.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	if c.Server.APIToken != "" {
+		secrets = append(secrets, c.Server.APIToken)
+	}
+	for _, receiver := range c.Notifications.Receivers {
+		if receiver.Slack != nil && receiver.Slack.Webhook != "" {
+			secrets = append(secrets, receiver.Slack.Webhook)
+		}
+		if receiver.Email != nil && receiver.Email.Password != "" {
+			secrets = append(secrets, receiver.Email.Password)
+		}
+	}
+	return secrets
+}
+
+// Redactor is a log writer that masks the registered secrets.
+type Redactor struct {
+	mu      sync.Mutex
+	out     io.Writer
+	secrets [][]byte
+}
+
+// NewRedactor writes to out with the secrets masked.
+func NewRedactor(out io.Writer) *Redactor {
+	return &Redactor{out: out}
+}
+
+// Add registers secrets to mask. Secrets of a previous configuration stay
+// registered, since they may still show up in errors.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		known := false
+		for _, existing := range r.secrets {
+			if string(existing) == secret {
+				known = true
+				break
+			}
+		}
+		if !known {
+			r.secrets = append(r.secrets, []byte(secret))
+		}
+	}
+
+	// Longer secrets first, so one containing another is fully masked.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+func (r *Redactor) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	redacted := p
+	for _, secret := range r.secrets {
+		redacted = bytes.ReplaceAll(redacted, secret, []byte("<redacted>"))
+	}
+	if _, err := r.out.Write(redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigReadsSecretsFromEnvironmentAndFiles(t *testing.T) {
+	t.Setenv("VELERO_NOTIFICATIONS_TEST_WEBHOOK", "https://hooks.slack.com/services/T0/B0/env")
+	t.Setenv("VELERO_NOTIFICATIONS_TEST_PASSWORD", `p@ss: "word" # not a comment`)
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("write password: %v", err)
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("api-token\n"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+
+	cfg, err := LoadConfig(writeConfig(t, `
+namespace: velero
+# Comments can mention ${UNSET_VARIABLES}.
+server:
+  listen_address: "127.0.0.1:8080"
+  api_token_file: "`+tokenFile+`"
+notifications:
+  receivers:
+    - name: team-a
+      slack:
+        webhook_url: "${VELERO_NOTIFICATIONS_TEST_WEBHOOK}"
+    - name: dba
+      email:
+        smtp_server: "smtp.example.com"
+        password_file: "`+passwordFile+`"
+    - name: oncall
+      email:
+        smtp_server: "smtp.example.com"
+        password_secret: {name: smtp, key: password}
+    - name: ops
+      email:
+        smtp_server: "smtp.example.com" # or ${UNSET_SMTP_SERVER}
+        password: ${VELERO_NOTIFICATIONS_TEST_PASSWORD}
+`))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	receivers := cfg.Notifications.Receivers
+	if webhook := receivers[0].Slack.Webhook; webhook != "https://hooks.slack.com/services/T0/B0/env" {
+		t.Fatalf("expected the webhook from the environment, got %q", webhook)
+	}
+	if password := receivers[1].Email.Password; password != "s3cret" {
+		t.Fatalf("expected the password from the file, got %q", password)
+	}
+	if password := receivers[3].Email.Password; password != `p@ss: "word" # not a comment` {
+		t.Fatalf("expected the password from the environment verbatim, got %q", password)
+	}
+	if namespace := receivers[2].Email.PasswordSecret.Namespace; namespace != "velero" {
+		t.Fatalf("expected the secret namespace to default to velero, got %q", namespace)
+	}
+
+	if token := cfg.Server.APIToken; token != "api-token" {
+		t.Fatalf("expected the API token from the file, got %q", token)
+	}
+
+	var lookups []SecretKeyRef
+	err = cfg.ResolveSecrets(func(ref SecretKeyRef) (string, error) {
+		lookups = append(lookups, ref)
+		return "from-secret", nil
+	})
+	if err != nil {
+		t.Fatalf("resolve secrets: %v", err)
+	}
+	if len(lookups) != 1 || lookups[0] != (SecretKeyRef{Namespace: "velero", Name: "smtp", Key: "password"}) {
+		t.Fatalf("expected one lookup of velero/smtp, got %v", lookups)
+	}
+	if password := receivers[2].Email.Password; password != "from-secret" {
+		t.Fatalf("expected the password from the secret, got %q", password)
+	}
+
+	lookupErr := errors.New("forbidden")
+	if err := cfg.ResolveSecrets(func(SecretKeyRef) (string, error) { return "", lookupErr }); !errors.Is(err, lookupErr) {
+		t.Fatalf("expected the lookup error, got %v", err)
+	}
+}
+
+func TestLoadConfigRejectsInvalidSecrets(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"unset variable": `
+notifications:
+  receivers:
+    - name: team-a
+      slack: {webhook_url: "${VELERO_NOTIFICATIONS_TEST_UNSET}"}
+`,
+		"several sources": `
+notifications:
+  receivers:
+    - name: team-a
+      slack:
+        webhook_url: "https://hooks.slack.com/a"
+        webhook_url_file: "/run/secrets/webhook"
+`,
+		"several API token sources": `
+server:
+  api_token: "a"
+  api_token_file: "/run/secrets/token"
+`,
+		"missing file": `
+notifications:
+  receivers:
+    - name: dba
+      email: {smtp_server: "smtp.example.com", password_file: "/nonexistent/password"}
+`,
+		"secret without key": `
+notifications:
+  receivers:
+    - name: dba
+      email:
+        smtp_server: "smtp.example.com"
+        password_secret: {name: smtp}
+`,
+	}
+
+	for name, content := range cases {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRedactorMasksSecrets(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	redactor := NewRedactor(&out)
+	redactor.Add("https://hooks.slack.com/services/T0/B0/XX", "hooks", "")
+
+	logger := log.New(redactor, "", 0)
+	logger.Printf(`Post "https://hooks.slack.com/services/T0/B0/XX": EOF`)
+
+	if got, want := out.String(), "Post \"<redacted>\": EOF\n"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Watch polls the config file and calls reload with every changed version
+// that loads and validates, or failed with the error of an invalid one.
+// Comparing the content instead of relying on file events also catches the
+// symlink swap Kubernetes uses to update a mounted ConfigMap.
+func Watch(ctx context.Context, path string, interval time.Duration, reload func(*Config), failed func(error)) {
+	current, err := os.ReadFile(path)
+	if err != nil {
+		failed(fmt.Errorf("read %s: %w", path, err))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// The file briefly disappears while a ConfigMap is updated.
+			continue
+		}
+		if bytes.Equal(data, current) {
+			continue
+		}
+		current = data
+
+		cfg, err := ParseConfig(data)
+		if err != nil {
+			failed(err)
+			continue
+		}
+		reload(cfg)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWatchReloadsValidChangesAndReportsInvalidOnes(t *testing.T) {
+	t.Parallel()
+
+	path := writeConfig(t, "check_interval: 30\n")
+
+	reloaded := make(chan *Config, 1)
+	failed := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, path, 10*time.Millisecond,
+		func(cfg *Config) { reloaded <- cfg },
+		func(err error) { failed <- err })
+	// Let the watcher read the initial content first.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(path, []byte("check_interval: 60\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	select {
+	case cfg := <-reloaded:
+		if cfg.CheckInterval != 60 {
+			t.Fatalf("expected the new check interval, got %d", cfg.CheckInterval)
+		}
+	case err := <-failed:
+		t.Fatalf("unexpected reload failure: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the change to be reloaded")
+	}
+
+	if err := os.WriteFile(path, []byte("check_interval: [\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	select {
+	case cfg := <-reloaded:
+		t.Fatalf("expected the invalid config to be rejected, got %+v", cfg)
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the invalid change to be reported")
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// Annotations on a Backup or Schedule that adjust its notifications. A
+// backup without any of them uses those of its Schedule, so setting them on
+// the Schedule covers both.
+const (
+	// ReceiversAnnotation lists comma-separated receivers that get the
+	// events in addition to the routed ones.
+	ReceiversAnnotation = "velero-notifications/receivers"
+	// FailuresOnlyAnnotation set to "true" drops the events that are not
+	// failures or recoveries.
+	FailuresOnlyAnnotation = "velero-notifications/failures-only"
+	// MuteAnnotation set to "true" drops every event.
+	MuteAnnotation = "velero-notifications/mute"
+	// MentionAnnotation lists comma-separated chat mentions.
+	MentionAnnotation = "velero-notifications/mention"
+)
+
+// annotationOverrides reads the notification overrides of an object.
+// Invalid values are logged and ignored.
+func annotationOverrides(kind, name string, annotations map[string]string) notifications.Overrides {
+	return notifications.Overrides{
+		Receivers:    splitAnnotation(annotations[ReceiversAnnotation]),
+		FailuresOnly: boolAnnotation(kind, name, annotations, FailuresOnlyAnnotation),
+		Mute:         boolAnnotation(kind, name, annotations, MuteAnnotation),
+		Mentions:     splitAnnotation(annotations[MentionAnnotation]),
+	}
+}
+
+// backupOverrides reads the overrides of a backup, falling back to the
+// annotations of the Schedule named in its velero.io/schedule-name label
+// when the backup sets none.
+func (vc *VeleroController) backupOverrides(backup *unstructured.Unstructured) notifications.Overrides {
+	annotations := backup.GetAnnotations()
+	schedule := backup.GetLabels()["velero.io/schedule-name"]
+	if hasOverrides(annotations) || schedule == "" {
+		return annotationOverrides("backup", backup.GetName(), annotations)
+	}
+
+	obj, err := vc.dynClient.Resource(schedulesGVR).Namespace(vc.Namespace).Get(context.TODO(), schedule, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Printf("Failed to retrieve schedule %s for the overrides of backup %s: %v", schedule, backup.GetName(), err)
+		}
+		return notifications.Overrides{}
+	}
+	return annotationOverrides("schedule", schedule, obj.GetAnnotations())
+}
+
+func hasOverrides(annotations map[string]string) bool {
+	for _, key := range []string{ReceiversAnnotation, FailuresOnlyAnnotation, MuteAnnotation, MentionAnnotation} {
+		if _, ok := annotations[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func boolAnnotation(kind, name string, annotations map[string]string, key string) bool {
+	value, ok := annotations[key]
+	if !ok {
+		return false
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		log.Printf("Ignoring %s annotation of %s %s: %q is not a boolean", key, kind, name, value)
+		return false
+	}
+	return enabled
+}
+
+func splitAnnotation(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
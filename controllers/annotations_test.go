@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+func TestBackupEventReadsAnnotationOverrides(t *testing.T) {
+	t.Parallel()
+
+	backup := veleroObject("Backup", "nightly-1", nil)
+	backup.SetAnnotations(map[string]string{
+		ReceiversAnnotation:    "team-a, team-a-oncall,",
+		FailuresOnlyAnnotation: "true",
+		MuteAnnotation:         "maybe",
+		MentionAnnotation:      "<!subteam^S123>",
+	})
+
+	vc, _ := newTestController(t)
+	event := vc.backupEvent(backup.Object, "Failed", "Backup nightly-1 failed.")
+
+	want := notifications.Overrides{
+		Receivers:    []string{"team-a", "team-a-oncall"},
+		FailuresOnly: true,
+		Mentions:     []string{"<!subteam^S123>"},
+	}
+	if !reflect.DeepEqual(event.Overrides, want) {
+		t.Fatalf("expected %+v, got %+v", want, event.Overrides)
+	}
+}
+
+func TestBackupEventFallsBackToScheduleOverrides(t *testing.T) {
+	t.Parallel()
+
+	schedule := veleroObject("Schedule", "nightly", nil)
+	schedule.SetAnnotations(map[string]string{ReceiversAnnotation: "team-a", MuteAnnotation: "false"})
+	vc, _ := newTestController(t, schedule)
+
+	backup := veleroObject("Backup", "nightly-1", nil)
+	backup.SetLabels(map[string]string{"velero.io/schedule-name": "nightly"})
+	event := vc.backupEvent(backup.Object, "Failed", "Backup nightly-1 failed.")
+	if want := []string{"team-a"}; !reflect.DeepEqual(event.Overrides.Receivers, want) {
+		t.Fatalf("expected the receivers of the schedule, got %+v", event.Overrides)
+	}
+
+	backup.SetAnnotations(map[string]string{MentionAnnotation: "<!here>"})
+	event = vc.backupEvent(backup.Object, "Failed", "Backup nightly-1 failed.")
+	want := notifications.Overrides{Mentions: []string{"<!here>"}}
+	if !reflect.DeepEqual(event.Overrides, want) {
+		t.Fatalf("expected only the overrides of the backup, got %+v", event.Overrides)
+	}
+}
+
+func TestScheduleAnnotationMutesScheduleEvents(t *testing.T) {
+	t.Parallel()
+
+	schedule := veleroObject("Schedule", "adhoc", nil)
+	schedule.SetAnnotations(map[string]string{MuteAnnotation: "true"})
+
+	vc, recorder := newTestController(t)
+	vc.notifyAll(scheduleEvent(schedule.Object, "Paused", "Schedule adhoc was paused."))
+
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected the muted schedule to send nothing, got %v", recorder.events)
+	}
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// DefaultKubeconfigSecretKey is the key of the kubeconfig in the
+// <cluster>-kubeconfig Secrets created by Cluster API.
+const DefaultKubeconfigSecretKey = "value"
+
+var secretsGVR = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "secrets",
+}
+
+// ClusterOptions describes a cluster watched from the management cluster.
+// Without a Context or a Secret the cluster the controller connected to is
+// used.
+type ClusterOptions struct {
+	// Name is shown in every notification of the cluster.
+	Name string
+	// Context selects a context of the local kubeconfig file.
+	Context string
+	// SecretNamespace, SecretName and SecretKey locate a Secret of the
+	// management cluster holding a kubeconfig.
+	SecretNamespace string
+	SecretName      string
+	SecretKey       string
+}
+
+// ForCluster returns a controller with the same options that watches another
+// cluster with its own client and state.
+func (vc *VeleroController) ForCluster(cluster ClusterOptions) (*VeleroController, error) {
+	client := vc.dynClient
+	if cluster.Context != "" || cluster.SecretName != "" {
+		config, err := vc.clusterConfig(cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err = dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("create client for cluster %s: %w", cluster.Name, err)
+		}
+	}
+
+	clone := *vc
+	clone.Cluster = cluster.Name
+	clone.dynClient = client
+	clone.resetState()
+	clone.applyReconfigure()
+	return &clone, nil
+}
+
+func (vc *VeleroController) clusterConfig(cluster ClusterOptions) (*rest.Config, error) {
+	if cluster.Context != "" {
+		if vc.kubeconfig == "" {
+			return nil, fmt.Errorf("cluster %s selects context %q but no kubeconfig file is in use", cluster.Name, cluster.Context)
+		}
+
+		config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: vc.kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: cluster.Context},
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("load context %q for cluster %s: %w", cluster.Context, cluster.Name, err)
+		}
+		return config, nil
+	}
+
+	key := cluster.SecretKey
+	if key == "" {
+		key = DefaultKubeconfigSecretKey
+	}
+
+	kubeconfig, err := vc.SecretValue(cluster.SecretNamespace, cluster.SecretName, key)
+	if err != nil {
+		return nil, fmt.Errorf("read kubeconfig of cluster %s: %w", cluster.Name, err)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("parse kubeconfig of cluster %s: %w", cluster.Name, err)
+	}
+	return config, nil
+}
+
+// RunClusters runs the namespaces of every cluster until the context is
+// done. A cluster whose client cannot be built is reported once and retried
+// on every check.
+func (vc *VeleroController) RunClusters(ctx context.Context, clusters []ClusterOptions, namespaces []string) {
+	for _, cluster := range clusters {
+		go vc.runCluster(ctx, cluster, namespaces)
+	}
+	<-ctx.Done()
+}
+
+func (vc *VeleroController) runCluster(ctx context.Context, cluster ClusterOptions, namespaces []string) {
+	reported := false
+	for {
+		clusterController, err := vc.ForCluster(cluster)
+		if err == nil {
+			if reported {
+				clusterController.notifyAll(notifications.Event{
+					Status:  "ContactRestored",
+					Message: fmt.Sprintf("Connected to cluster %s.", cluster.Name),
+				})
+			}
+			log.Printf("Watching cluster %s.", cluster.Name)
+			clusterController.RunNamespaces(ctx, namespaces)
+			return
+		}
+
+		log.Printf("Failed to connect to cluster %s: %v", cluster.Name, err)
+		if !reported {
+			reported = true
+			// Dispatched directly, as notifyAll would tag the event with
+			// the cluster of this controller.
+			event := notifications.Event{
+				Status:  "ContactLost",
+				Message: fmt.Sprintf("Cannot connect to cluster %s.\nFailure Reason: %v", cluster.Name, err),
+				Cluster: cluster.Name,
+			}
+			if err := vc.Router.Dispatch(event); err != nil {
+				log.Printf("Error sending notifications: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vc.Interval):
+		}
+	}
+}
+
+// SecretValue returns a key of a Secret in the cluster the controller
+// connected to.
+func (vc *VeleroController) SecretValue(namespace, name, key string) (string, error) {
+	secret, err := vc.dynClient.Resource(secretsGVR).Namespace(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get secret %s/%s: %w", namespace, name, err)
+	}
+
+	encoded, found, _ := unstructured.NestedString(secret.Object, "data", key)
+	if !found {
+		return "", fmt.Errorf("secret %s/%s has no key %q", namespace, name, key)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode secret %s/%s: %w", namespace, name, err)
+	}
+	return string(value), nil
+}
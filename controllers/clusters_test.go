@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: workload
+  cluster:
+    server: https://workload.example.com:6443
+contexts:
+- name: workload
+  context:
+    cluster: workload
+    user: admin
+current-context: workload
+users:
+- name: admin
+  user:
+    token: secret-token
+`
+
+func kubeconfigSecret(name string, data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "capi"},
+		"data":       data,
+	}}
+}
+
+func TestForClusterReadsKubeconfigSecret(t *testing.T) {
+	t.Parallel()
+
+	secret := kubeconfigSecret("workload-kubeconfig", map[string]interface{}{
+		DefaultKubeconfigSecretKey: base64.StdEncoding.EncodeToString([]byte(testKubeconfig)),
+	})
+	vc, _ := newTestController(t, secret)
+	vc.processedBackups["nightly-1"] = "Completed"
+
+	cluster := ClusterOptions{Name: "workload", SecretNamespace: "capi", SecretName: "workload-kubeconfig"}
+	config, err := vc.clusterConfig(cluster)
+	if err != nil {
+		t.Fatalf("cluster config: %v", err)
+	}
+	if config.Host != "https://workload.example.com:6443" || config.BearerToken != "secret-token" {
+		t.Fatalf("unexpected config %s %q", config.Host, config.BearerToken)
+	}
+
+	workload, err := vc.ForCluster(cluster)
+	if err != nil {
+		t.Fatalf("for cluster: %v", err)
+	}
+	if workload.Cluster != "workload" || workload.dynClient == vc.dynClient || len(workload.processedBackups) != 0 {
+		t.Fatal("expected a controller with its own client and state")
+	}
+}
+
+func TestForClusterReportsInvalidSources(t *testing.T) {
+	t.Parallel()
+
+	vc, _ := newTestController(t, kubeconfigSecret("empty", map[string]interface{}{"other": "eA=="}))
+
+	cases := map[string]ClusterOptions{
+		"missing secret": {Name: "a", SecretNamespace: "capi", SecretName: "missing"},
+		"missing key":    {Name: "b", SecretNamespace: "capi", SecretName: "empty"},
+		"no kubeconfig":  {Name: "c", Context: "prod"},
+	}
+	for name, cluster := range cases {
+		if _, err := vc.ForCluster(cluster); err == nil || !strings.Contains(err.Error(), cluster.Name) {
+			t.Fatalf("%s: expected an error naming the cluster, got %v", name, err)
+		}
+	}
+
+	local, err := vc.ForCluster(ClusterOptions{Name: "management"})
+	if err != nil || local.dynClient != vc.dynClient {
+		t.Fatalf("expected the local client to be reused, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,13 +22,59 @@ import (
 	"github.com/zokeber/velero-notifications/notifications"
 )
 
+var backupsGVR = schema.GroupVersionResource{
+	Group:    "velero.io",
+	Version:  "v1",
+	Resource: "backups",
+}
+
 type VeleroController struct {
+	// Cluster is the display name of the watched cluster, added to every
+	// event. It is empty for the cluster the controller runs in.
+	Cluster          string
 	Namespace        string
 	Interval         time.Duration
 	Verbose          bool
-	Notifiers        []notifications.Notifier
+	Router           *notifications.Router
+	BackupLogs       BackupLogsOptions
+	BackupResults    BackupResultsOptions
+	MissedSchedules  MissedSchedulesOptions
+	StuckBackups     StuckBackupsOptions
+	Regressions      RegressionsOptions
+	StorageLocations StorageLocationsOptions
+	Snapshots        SnapshotsOptions
+	VolumeBackups    VolumeBackupsOptions
+	Deletions        DeletionsOptions
+	Recoveries       RecoveriesOptions
+	Digests          []DigestOptions
+	Health           HealthOptions
+	Silences         SilencesOptions
+	Filters          FiltersOptions
 	dynClient        dynamic.Interface
+	// kubeconfig is the kubeconfig file the client was built from, if any.
+	kubeconfig string
+	// reconfig is shared with the clones of the controller.
+	reconfig         *reconfigurer
+	reconfigVersion  int
 	processedBackups map[string]string
+	schedules        map[string]*scheduleState
+	stuckBackups     map[string]time.Time
+	baselines        map[string]*scheduleBaseline
+	storageLocations map[string]*storageLocationState
+	knownBackups     map[string]map[string]interface{}
+	expiryWarnings   map[string]bool
+	streaks          map[string]*scheduleStreak
+	digests          map[string]*digestState
+	health           healthState
+	// silencesSynced is when the silences were last read from the cluster.
+	silencesSynced time.Time
+	// processedRestores holds the restores seen running, by name.
+	processedRestores map[string]string
+	// deleteRequests is nil until the first list of DeleteBackupRequests.
+	deleteRequests map[string]bool
+	// downloads tracks the notifications waiting for backup logs or
+	// results.
+	downloads *sync.WaitGroup
 }
 
 func formatTime(tStr string) string {
@@ -38,10 +85,11 @@ func formatTime(tStr string) string {
 	return t.Format("01/02/06 at 3:04 PM MST")
 }
 
-func NewVeleroController(namespace string, checkInterval int, verbose bool, notifiers []notifications.Notifier) (*VeleroController, error) {
+func NewVeleroController(namespace string, checkInterval int, verbose bool, router *notifications.Router) (*VeleroController, error) {
 	var kubeconfig *string
 	var config *rest.Config
 	var err error
+	var kubeconfigPath string
 
 	if home := homedir.HomeDir(); home != "" {
 		kubeconfig = flag.String("kubeconfig", filepath.Join(home, ".kube", "config"), "(Optional) Absolute path to the kubeconfig file")
@@ -57,6 +105,7 @@ func NewVeleroController(namespace string, checkInterval int, verbose bool, noti
 			if err != nil {
 				log.Fatalf("Failed to build kubeconfig from flag: %v", err)
 			}
+			kubeconfigPath = *kubeconfig
 			log.Println("Using local kubeconfig to connect to the cluster.")
 		} else {
 			config, err = rest.InClusterConfig()
@@ -83,39 +132,103 @@ func NewVeleroController(namespace string, checkInterval int, verbose bool, noti
 		log.Printf("Successfully connected to the Kubernetes API server in namespace '%s'.", namespace)
 	}
 
-	return &VeleroController{
-		Namespace:        namespace,
-		Interval:         time.Duration(checkInterval) * time.Second,
-		Verbose:          verbose,
-		Notifiers:        notifiers,
-		dynClient:        dynClient,
-		processedBackups: make(map[string]string),
-	}, nil
+	vc := &VeleroController{
+		Namespace:  namespace,
+		Interval:   time.Duration(checkInterval) * time.Second,
+		Verbose:    verbose,
+		Router:     router,
+		dynClient:  dynClient,
+		kubeconfig: kubeconfigPath,
+		reconfig:   &reconfigurer{},
+	}
+	vc.resetState()
+	return vc, nil
+}
+
+// resetState gives the controller empty tracking state.
+func (vc *VeleroController) resetState() {
+	vc.processedBackups = make(map[string]string)
+	vc.processedRestores = make(map[string]string)
+	vc.silencesSynced = time.Time{}
+	vc.schedules = make(map[string]*scheduleState)
+	vc.stuckBackups = make(map[string]time.Time)
+	vc.baselines = make(map[string]*scheduleBaseline)
+	vc.storageLocations = make(map[string]*storageLocationState)
+	vc.knownBackups = make(map[string]map[string]interface{})
+	vc.expiryWarnings = make(map[string]bool)
+	vc.streaks = make(map[string]*scheduleStreak)
+	vc.digests = make(map[string]*digestState)
+	vc.health = healthState{}
+	vc.deleteRequests = nil
+	vc.downloads = &sync.WaitGroup{}
+}
+
+// waitForDownloads lets the notifications still fetching backup logs or
+// results be sent, for up to timeout.
+func (vc *VeleroController) waitForDownloads(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		vc.downloads.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("Gave up waiting for backup downloads after %s.", timeout)
+	}
 }
 
 func (vc *VeleroController) Run(ctx context.Context) {
 	ticker := time.NewTicker(vc.Interval)
 	defer ticker.Stop()
 
+	vc.checkVeleroCRDs()
+
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Shutting down Velero Controller.")
+			vc.waitForDownloads(downloadShutdownTimeout)
 			return
 		case <-ticker.C:
+			vc.applyReconfigure()
+			if vc.Silences.Silencer != nil && vc.silencesDue(time.Now()) {
+				vc.syncSilences()
+			}
+			if vc.StorageLocations.Enabled {
+				vc.checkStorageLocations()
+			}
 			vc.checkBackups()
+			if vc.VolumeBackups.Enabled {
+				vc.checkRestores()
+			}
+			if vc.Deletions.Enabled {
+				vc.checkDeleteRequests()
+			}
+			if vc.MissedSchedules.Enabled {
+				vc.checkSchedules()
+			}
+			if len(vc.Digests) > 0 {
+				vc.checkDigests(time.Now())
+			}
 		}
 	}
 }
 
-func (vc *VeleroController) notifyAll(status, message string) {
-	for _, notifier := range vc.Notifiers {
-		if err := notifier.Notify(status, message); err != nil {
-			log.Printf("Error sending notifications: %v", err)
-		}
+func (vc *VeleroController) notifyAll(event notifications.Event) {
+	if err := vc.Router.Dispatch(vc.scoped(event)); err != nil {
+		log.Printf("Error sending notifications: %v", err)
 	}
 }
 
+// scoped tags an event with the cluster and Velero namespace it comes from.
+func (vc *VeleroController) scoped(event notifications.Event) notifications.Event {
+	event.Cluster = vc.Cluster
+	event.VeleroNamespace = vc.Namespace
+	return event
+}
+
 func extractWarnings(obj map[string]interface{}) int {
 	warnings := 0
 	if w, found, err := unstructured.NestedFieldCopy(obj, "status", "warnings"); err == nil && found {
@@ -154,34 +267,82 @@ func extractErrors(obj map[string]interface{}) int {
 	return errorsCount
 }
 
-func (vc *VeleroController) checkBackups() {
-	backupsGVR := schema.GroupVersionResource{
-		Group:    "velero.io",
-		Version:  "v1",
-		Resource: "backups",
+// backupEvent builds a notification event carrying the backup metadata the
+// router matches on.
+// includedNamespaces returns the includedNamespaces list at fields, or "*"
+// when it is empty, since Velero then covers every namespace.
+func includedNamespaces(obj map[string]interface{}, fields ...string) []string {
+	namespaces, _, _ := unstructured.NestedStringSlice(obj, fields...)
+	if len(namespaces) == 0 {
+		return []string{"*"}
+	}
+	return namespaces
+}
+
+func (vc *VeleroController) backupEvent(obj map[string]interface{}, phase, message string) notifications.Event {
+	backup := unstructured.Unstructured{Object: obj}
+	labels := backup.GetLabels()
+	storageLocation, _, _ := unstructured.NestedString(obj, "spec", "storageLocation")
+
+	return notifications.Event{
+		Status:          phase,
+		Message:         message,
+		BackupName:      backup.GetName(),
+		Schedule:        labels["velero.io/schedule-name"],
+		Labels:          labels,
+		Namespaces:      includedNamespaces(obj, "spec", "includedNamespaces"),
+		StorageLocation: storageLocation,
+		Warnings:        extractWarnings(obj),
+		Errors:          extractErrors(obj),
+		Overrides:       vc.backupOverrides(&backup),
 	}
+}
 
-	list, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{})
+func (vc *VeleroController) checkBackups() {
+	list, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: vc.Filters.LabelSelector})
 
 	if err != nil {
 		log.Printf("Failed to retrieving backups from Velero: %v", err)
-		vc.notifyAll("Error", fmt.Sprintf("Failed to retrieving backups from Velero: %v", err))
+		vc.recordAPIFailure(err)
 		return
 	}
+	vc.recordAPISuccess()
 
 	if vc.Verbose {
 		log.Printf("Found %d backups in namespace '%s'.", len(list.Items), vc.Namespace)
 	}
 
+	now := time.Now()
+	seen := make(map[string]bool, len(list.Items))
 	for _, item := range list.Items {
+		if !vc.Filters.allowsBackup(&item) {
+			continue
+		}
+
 		backupName, _, _ := unstructured.NestedString(item.Object, "metadata", "name")
+		seen[backupName] = true
 		phase, found, err := unstructured.NestedString(item.Object, "status", "phase")
 		if err != nil || !found {
 			log.Printf("Backup %s is not supported.", backupName)
 			continue
 		}
 
+		if vc.Deletions.Enabled {
+			vc.checkDeletion(item.Object, backupName, phase)
+			vc.checkExpiry(item.Object, backupName, phase, now)
+		}
+
+		if vc.StuckBackups.Enabled && isRunningPhase(phase) {
+			vc.checkStuckBackup(item.Object, backupName, phase, now)
+		}
+
 		if _, exists := vc.processedBackups[backupName]; !exists {
+			if vc.Regressions.Enabled && phase == "Completed" {
+				vc.recordBaseline(item.Object)
+			}
+			if vc.Recoveries.Enabled {
+				vc.recordOutcome(item.Object, phase)
+			}
 			if phase == "InProgress" || phase == "Finalizing" || phase == "WaitingForPluginOperations" {
 				vc.processedBackups[backupName] = phase
 				if vc.Verbose {
@@ -212,8 +373,6 @@ func (vc *VeleroController) checkBackups() {
 				}
 			}
 
-			warnings := extractWarnings(item.Object)
-			errorsCount := extractErrors(item.Object)
 			failureReason := ""
 
 			if phase == "Failed" {
@@ -222,26 +381,83 @@ func (vc *VeleroController) checkBackups() {
 				}
 			}
 
+			snapshots, incompleteSnapshots := "", false
+			if vc.Snapshots.Enabled {
+				snapshots, incompleteSnapshots = snapshotSummary(item.Object)
+			}
+
+			recoveredFrom, lastSuccess := 0, time.Time{}
+			if vc.Recoveries.Enabled {
+				recoveredFrom, lastSuccess = vc.recordOutcome(item.Object, phase)
+			}
+
+			status := phase
 			var message string
-			if phase == "Completed" {
+			if recoveredFrom > 0 && recoveredFrom >= vc.Recoveries.MinFailures {
+				status = "Recovered"
+				schedule, _, _ := unstructured.NestedString(item.Object, "metadata", "labels", "velero.io/schedule-name")
+				message = fmt.Sprintf("Backup %s of schedule %s: %s.\n\nStart Time: %s, End Time: %s.\n\nProgress: %s/%s items processed", backupName, schedule, recoveryNote(recoveredFrom, lastSuccess, backupCompletionTime(item.Object)), formatTime(startTimestamp), formatTime(completionTimestamp), itemsBackedUp, totalItems)
+			} else if phase == "Completed" && incompleteSnapshots {
+				status = "IncompleteSnapshots"
+				message = fmt.Sprintf("Backup %s completed with incomplete volume snapshots.\n\nStart Time: %s, End Time: %s.\n\nProgress: %s/%s items processed", backupName, formatTime(startTimestamp), formatTime(completionTimestamp), itemsBackedUp, totalItems)
+			} else if phase == "Completed" {
 				message = fmt.Sprintf("Backup %s completed successfully.\n\nStart Time: %s, End Time: %s.\n\nProgress: %s/%s items processed", backupName, formatTime(startTimestamp), formatTime(completionTimestamp), itemsBackedUp, totalItems)
 			} else {
 				message = fmt.Sprintf("Backup %s finished with status: %s.\n\nStart Time: %s, End Time: %s.\n\nProgress: %s/%s items processed", backupName, phase, formatTime(startTimestamp), formatTime(completionTimestamp), itemsBackedUp, totalItems)
 				if failureReason != "" {
 					message += fmt.Sprintf("\nFailure Reason: %s", failureReason)
 				}
+				if vc.StorageLocations.Enabled {
+					storageLocation, _, _ := unstructured.NestedString(item.Object, "spec", "storageLocation")
+					message += vc.storageLocationNote(storageLocation)
+				}
 			}
 
-			if warnings > 0 {
-				message += fmt.Sprintf(" (with %d warnings).", warnings)
+			var volumes []notifications.VolumeStatus
+			if vc.VolumeBackups.Enabled {
+				volumes, err = vc.collectVolumeBackups(context.TODO(), backupName)
+				if err != nil {
+					log.Printf("Failed to retrieve volume backups for backup %s: %v", backupName, err)
+				}
 			}
 
-			if errorsCount > 0 {
-				message += fmt.Sprintf(" (with %d errors).", errorsCount)
-			}
+			message += snapshots
+			message += volumeBackupSummary(volumes)
+			followUp := vc.stuckFollowUp(item.Object, backupName)
+			message += followUp
 
 			log.Println(message)
-			vc.notifyAll(phase, message)
+
+			event := vc.backupEvent(item.Object, status, message)
+			event.Phase = phase
+			event.FollowUp = followUp != ""
+			if incompleteSnapshots && vc.Snapshots.VolumeDetails {
+				infos, err := vc.collectVolumeInfos(context.TODO(), backupName)
+				if err != nil {
+					log.Printf("Failed to retrieve volume infos for backup %s: %v", backupName, err)
+				}
+				volumes = mergeVolumeInfos(volumes, infos)
+			}
+			event.Volumes = volumes
+			fetchResults := vc.BackupResults.Enabled && (event.Warnings > 0 || event.Errors > 0)
+			fetchLogs := vc.BackupLogs.Enabled && (phase == "Failed" || phase == "PartiallyFailed")
+			if fetchResults || fetchLogs {
+				// DownloadRequests wait on the object store for up to their
+				// timeout, so they run off the check loop with a copy of the
+				// current options.
+				snapshot := *vc
+				vc.downloads.Add(1)
+				go func() {
+					defer vc.downloads.Done()
+					snapshot.notifyWithDownloads(event, backupName, fetchResults, fetchLogs)
+				}()
+			} else {
+				vc.notifyAll(event)
+			}
+
+			if vc.Regressions.Enabled && phase == "Completed" {
+				vc.checkRegression(item.Object)
+			}
 
 			delete(vc.processedBackups, backupName)
 		}
@@ -250,4 +466,24 @@ func (vc *VeleroController) checkBackups() {
 			log.Printf("Backup %s is still in %s.", backupName, phase)
 		}
 	}
+
+	for name := range vc.stuckBackups {
+		if !seen[name] {
+			delete(vc.stuckBackups, name)
+		}
+	}
+
+	for _, baseline := range vc.baselines {
+		baseline.forget(seen)
+	}
+
+	for name, obj := range vc.knownBackups {
+		if !seen[name] {
+			delete(vc.knownBackups, name)
+			delete(vc.expiryWarnings, name)
+			if vc.Deletions.Enabled {
+				vc.checkVanishedBackup(obj, name)
+			}
+		}
+	}
 }
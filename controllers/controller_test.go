@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+type recordingNotifier struct {
+	events []notifications.Event
+}
+
+func (r *recordingNotifier) Notify(status, message string) error {
+	return r.NotifyEvent(notifications.Event{Status: status, Message: message})
+}
+
+func (r *recordingNotifier) NotifyEvent(event notifications.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingNotifier) statuses() []string {
+	statuses := make([]string, 0, len(r.events))
+	for _, event := range r.events {
+		statuses = append(statuses, event.Status)
+	}
+	return statuses
+}
+
+// newTestController returns a controller backed by a fake dynamic client
+// seeded with the given Velero objects, and the notifier that records the
+// events it sends.
+func newTestController(t *testing.T, objects ...runtime.Object) (*VeleroController, *recordingNotifier) {
+	t.Helper()
+
+	recorder := &recordingNotifier{}
+	router, err := notifications.NewRouter(notifications.Route{}, map[string]notifications.Notifier{"test": recorder})
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}:                                 "ConfigMapList",
+		{Version: "v1", Resource: "namespaces"}:                                 "NamespaceList",
+		{Version: "v1", Resource: "nodes"}:                                      "NodeList",
+		{Version: "v1", Resource: "pods"}:                                       "PodList",
+		{Group: "velero.io", Version: "v1", Resource: "backups"}:                "BackupList",
+		{Group: "velero.io", Version: "v1", Resource: "schedules"}:              "ScheduleList",
+		{Group: "velero.io", Version: "v1", Resource: "backupstoragelocations"}: "BackupStorageLocationList",
+		{Group: "velero.io", Version: "v1", Resource: "deletebackuprequests"}:   "DeleteBackupRequestList",
+		{Group: "velero.io", Version: "v1", Resource: "podvolumebackups"}:       "PodVolumeBackupList",
+		{Group: "velero.io", Version: "v1", Resource: "podvolumerestores"}:      "PodVolumeRestoreList",
+		{Group: "velero.io", Version: "v1", Resource: "restores"}:               "RestoreList",
+		{Group: "velero.io", Version: "v2alpha1", Resource: "datauploads"}:      "DataUploadList",
+		{Group: "velero.io", Version: "v2alpha1", Resource: "datadownloads"}:    "DataDownloadList",
+	}
+
+	vc := &VeleroController{
+		Namespace: "velero",
+		Router:    router,
+		dynClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+		reconfig:  &reconfigurer{},
+	}
+	vc.resetState()
+	return vc, recorder
+}
+
+func veleroObject(kind, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := map[string]interface{}{
+		"apiVersion": "velero.io/v1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "velero",
+		},
+	}
+	for key, value := range fields {
+		obj[key] = value
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func TestCheckBackupsCarriesCountsOnTheEvent(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t)
+	backup := veleroObject("Backup", "nightly-1", map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Failed", "failureReason": "timed out", "warnings": int64(3), "errors": int64(2)},
+	})
+	if _, err := vc.dynClient.Resource(backupsGVR).Namespace("velero").Create(context.TODO(), backup, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	vc.processedBackups["nightly-1"] = "InProgress"
+
+	vc.checkBackups()
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected one event, got %v", recorder.statuses())
+	}
+	event := recorder.events[0]
+	if event.Warnings != 3 || event.Errors != 2 {
+		t.Fatalf("expected 3 warnings and 2 errors, got %d and %d", event.Warnings, event.Errors)
+	}
+	if strings.Contains(event.Message, "(with") {
+		t.Fatalf("expected the counts to stay out of the message, got %q", event.Message)
+	}
+}
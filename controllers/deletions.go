@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+var deleteBackupRequestsGVR = schema.GroupVersionResource{
+	Group:    "velero.io",
+	Version:  "v1",
+	Resource: "deletebackuprequests",
+}
+
+// DeletionsOptions controls the notifications about backups being deleted
+// and about long-term retention backups that are about to expire.
+type DeletionsOptions struct {
+	Enabled bool
+	// ExpiryWarning is how long before status.expiration the heads-up is
+	// sent. Zero disables it.
+	ExpiryWarning time.Duration
+	// RetentionSelector picks the backups that get an expiry heads-up.
+	RetentionSelector labels.Selector
+}
+
+// checkDeletion notifies when a backup the controller has already seen
+// moves to the Deleting phase.
+func (vc *VeleroController) checkDeletion(obj map[string]interface{}, backupName, phase string) {
+	last, known := vc.knownBackups[backupName]
+	vc.knownBackups[backupName] = obj
+	previous, _, _ := unstructured.NestedString(last, "status", "phase")
+	if phase != "Deleting" || !known || previous == "Deleting" {
+		return
+	}
+
+	message := fmt.Sprintf("Backup %s is being deleted.", backupName)
+	if expiration := nestedTime(obj, "status", "expiration"); !expiration.IsZero() {
+		message += "\n\nExpiration: " + formatTime(expiration.Format(time.RFC3339))
+	}
+	log.Println(message)
+	vc.notifyAll(vc.backupEvent(obj, "Deleting", message))
+}
+
+// checkVanishedBackup notifies when a backup the controller has seen is
+// gone before it was seen Deleting, as when it is deleted between two
+// checks. The backup must be missing from the API, not just from the list,
+// so a change of filters is not reported as a deletion.
+func (vc *VeleroController) checkVanishedBackup(obj map[string]interface{}, backupName string) {
+	if phase, _, _ := unstructured.NestedString(obj, "status", "phase"); phase == "Deleting" {
+		return
+	}
+	_, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).Get(context.TODO(), backupName, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		return
+	}
+
+	message := fmt.Sprintf("Backup %s was deleted.", backupName)
+	log.Println(message)
+	vc.notifyAll(vc.backupEvent(obj, "Deleted", message))
+}
+
+// checkExpiry sends a single heads-up when a retained backup gets within
+// the warning period of its expiration.
+func (vc *VeleroController) checkExpiry(obj map[string]interface{}, backupName, phase string, now time.Time) {
+	if vc.Deletions.ExpiryWarning <= 0 || vc.Deletions.RetentionSelector == nil || vc.expiryWarnings[backupName] {
+		return
+	}
+
+	if phase == "Deleting" || !vc.Deletions.RetentionSelector.Matches(labels.Set((&unstructured.Unstructured{Object: obj}).GetLabels())) {
+		return
+	}
+
+	expiration := nestedTime(obj, "status", "expiration")
+	if expiration.IsZero() || expiration.Sub(now) > vc.Deletions.ExpiryWarning {
+		return
+	}
+
+	message := fmt.Sprintf("Backup %s expires in %s.\n\nExpiration: %s", backupName, notifications.FormatDuration(max(expiration.Sub(now), 0)), formatTime(expiration.Format(time.RFC3339)))
+	log.Println(message)
+	vc.notifyAll(vc.backupEvent(obj, "Expiring", message))
+	vc.expiryWarnings[backupName] = true
+}
+
+// checkDeleteRequests notifies once about every DeleteBackupRequest Velero
+// processed with errors. Requests that already exist at startup are only
+// recorded, so a restart does not repeat old failures.
+func (vc *VeleroController) checkDeleteRequests() {
+	list, err := vc.dynClient.Resource(deleteBackupRequestsGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to retrieving delete backup requests from Velero: %v", err)
+		return
+	}
+
+	seeding := vc.deleteRequests == nil
+	if seeding {
+		vc.deleteRequests = make(map[string]bool, len(list.Items))
+	}
+
+	seen := make(map[string]bool, len(list.Items))
+	for _, item := range list.Items {
+		name := item.GetName()
+		seen[name] = true
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if phase != "Processed" || vc.deleteRequests[name] {
+			continue
+		}
+		vc.deleteRequests[name] = true
+
+		requestErrors, _, _ := unstructured.NestedStringSlice(item.Object, "status", "errors")
+		if seeding || len(requestErrors) == 0 {
+			continue
+		}
+
+		backupName, _, _ := unstructured.NestedString(item.Object, "spec", "backupName")
+		message := fmt.Sprintf("Deletion of backup %s failed.\nFailure Reason: %s", backupName, strings.Join(requestErrors, "; "))
+		// The backup usually survives a failed deletion; its metadata lets
+		// the router match the event like any other backup event.
+		event := notifications.Event{Status: "DeletionFailed", Message: message, BackupName: backupName}
+		if backup, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).Get(context.TODO(), backupName, metav1.GetOptions{}); err == nil {
+			if !vc.Filters.allowsBackup(backup) || !vc.Filters.selects(backup) {
+				continue
+			}
+			event = vc.backupEvent(backup.Object, "DeletionFailed", message)
+		} else if !vc.Filters.Backups.allows(backupName) {
+			continue
+		}
+		log.Println(message)
+		vc.notifyAll(event)
+	}
+
+	for name := range vc.deleteRequests {
+		if !seen[name] {
+			delete(vc.deleteRequests, name)
+		}
+	}
+}
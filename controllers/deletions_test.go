@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCheckBackupsNotifiesDeletionAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Now().Add(72 * time.Hour).UTC()
+	retained := veleroObject("Backup", "yearly-2025", map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":      "Completed",
+			"expiration": expiration.Format(time.RFC3339),
+		},
+	})
+	retained.SetLabels(map[string]string{"retention": "long-term"})
+
+	vc, recorder := newTestController(t, retained, veleroObject("Backup", "daily-20260318", map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":      "Completed",
+			"expiration": expiration.Format(time.RFC3339),
+		},
+	}))
+	vc.Deletions = DeletionsOptions{
+		Enabled:           true,
+		ExpiryWarning:     7 * 24 * time.Hour,
+		RetentionSelector: labels.SelectorFromSet(labels.Set{"retention": "long-term"}),
+	}
+
+	vc.checkBackups()
+	vc.checkBackups()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Expiring"}) {
+		t.Fatalf("expected a single heads-up for the retained backup, got %v", got)
+	}
+	if event := recorder.events[0]; event.BackupName != "yearly-2025" || !strings.Contains(event.Message, "expires in 3d") {
+		t.Fatalf("unexpected expiry event %+v", event)
+	}
+
+	client := vc.dynClient.Resource(backupsGVR).Namespace("velero")
+	backup, err := client.Get(context.TODO(), "daily-20260318", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get backup: %v", err)
+	}
+	_ = unstructured.SetNestedField(backup.Object, "Deleting", "status", "phase")
+	if _, err := client.Update(context.TODO(), backup, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update backup: %v", err)
+	}
+
+	vc.checkBackups()
+	vc.checkBackups()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Expiring", "Deleting"}) {
+		t.Fatalf("expected a single deleting notification, got %v", got)
+	}
+}
+
+func TestCheckDeleteRequestsReportsNewFailuresOnly(t *testing.T) {
+	t.Parallel()
+
+	request := func(name, backup string, errs ...interface{}) *unstructured.Unstructured {
+		return veleroObject("DeleteBackupRequest", name, map[string]interface{}{
+			"spec":   map[string]interface{}{"backupName": backup},
+			"status": map[string]interface{}{"phase": "Processed", "errors": errs},
+		})
+	}
+
+	vc, recorder := newTestController(t, request("old-x1", "old", "error deleting backup from storage"))
+
+	vc.checkDeleteRequests()
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected failures found at startup to be recorded only, got %v", recorder.statuses())
+	}
+
+	client := vc.dynClient.Resource(deleteBackupRequestsGVR).Namespace("velero")
+	for _, obj := range []*unstructured.Unstructured{
+		request("daily-a1", "daily", "error deleting snapshot snap-123: AccessDenied"),
+		request("weekly-b2", "weekly"),
+	} {
+		if _, err := client.Create(context.TODO(), obj, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+	}
+
+	// Backups outside the label selector are not reported.
+	vc.Filters.LabelSelector = "team=a"
+	other := veleroObject("Backup", "other", nil)
+	other.SetLabels(map[string]string{"team": "b"})
+	if _, err := vc.dynClient.Resource(backupsGVR).Namespace("velero").Create(context.TODO(), other, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	if _, err := client.Create(context.TODO(), request("other-c3", "other", "error deleting backup from storage"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	vc.checkDeleteRequests()
+	vc.checkDeleteRequests()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"DeletionFailed"}) {
+		t.Fatalf("expected a single deletion failure, got %v", got)
+	}
+	if event := recorder.events[0]; event.BackupName != "daily" || !strings.Contains(event.Message, "AccessDenied") {
+		t.Fatalf("unexpected deletion failure event %+v", event)
+	}
+}
+
+func TestCheckBackupsReportsBackupsDeletedBetweenChecks(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t,
+		veleroObject("Backup", "daily-20260317", map[string]interface{}{"status": map[string]interface{}{"phase": "Completed"}}),
+		veleroObject("Backup", "daily-20260318", map[string]interface{}{"status": map[string]interface{}{"phase": "Completed"}}),
+	)
+	vc.Deletions = DeletionsOptions{Enabled: true}
+
+	vc.checkBackups()
+
+	client := vc.dynClient.Resource(backupsGVR).Namespace("velero")
+	if err := client.Delete(context.TODO(), "daily-20260317", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete backup: %v", err)
+	}
+	// A backup that leaves the list without being deleted is not reported.
+	vc.Filters.Backups, _ = NewNameFilter(nil, []string{"daily-20260318"})
+
+	vc.checkBackups()
+	vc.checkBackups()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Deleted"}) {
+		t.Fatalf("expected a single deleted notification, got %v", got)
+	}
+	if event := recorder.events[0]; event.BackupName != "daily-20260317" {
+		t.Fatalf("unexpected deleted event %+v", event)
+	}
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// DigestOptions describes a periodic summary of the finished backups.
+type DigestOptions struct {
+	Name     string
+	Schedule cron.Schedule
+	// Receivers get the digest regardless of the routing tree. Empty means
+	// the digest is routed like any other event.
+	Receivers []string
+}
+
+type digestState struct {
+	since time.Time
+	next  time.Time
+}
+
+// checkDigests sends every digest whose run time has passed. A digest
+// covers the backups that finished since its previous run; the first one
+// after startup covers one schedule period ending at its first run. Each
+// controller sends its own digest, covering its Velero installation.
+func (vc *VeleroController) checkDigests(now time.Time) {
+	for _, digest := range vc.Digests {
+		state, ok := vc.digests[digest.Name]
+		if !ok {
+			next := digest.Schedule.Next(now)
+			period := digest.Schedule.Next(next).Sub(next)
+			state = &digestState{since: next.Add(-period), next: next}
+			vc.digests[digest.Name] = state
+		}
+
+		if now.Before(state.next) {
+			continue
+		}
+
+		summary, err := vc.buildDigest(digest.Name, state.since, state.next)
+		if err != nil {
+			log.Printf("Failed to build digest %s: %v", digest.Name, err)
+			continue
+		}
+
+		event := digestEvent(summary)
+		log.Println(event.Message)
+		if len(digest.Receivers) > 0 {
+			if err := vc.Router.DispatchTo(vc.scoped(event), digest.Receivers); err != nil {
+				log.Printf("Error sending notifications: %v", err)
+			}
+		} else {
+			vc.notifyAll(event)
+		}
+
+		state.since = state.next
+		state.next = digest.Schedule.Next(now)
+	}
+}
+
+// buildDigest aggregates the backups that finished in (since, until] by
+// schedule.
+func (vc *VeleroController) buildDigest(name string, since, until time.Time) (*notifications.Digest, error) {
+	list, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: vc.Filters.LabelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+
+	rows := make(map[string]*notifications.DigestSchedule)
+	lastFailures := make(map[string]time.Time)
+	for _, item := range list.Items {
+		if !vc.Filters.allowsBackup(&item) {
+			continue
+		}
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		completed := backupCompletionTime(item.Object)
+		if (phase != "Completed" && !isFailedPhase(phase)) || !completed.After(since) || completed.After(until) {
+			continue
+		}
+
+		schedule := item.GetLabels()["velero.io/schedule-name"]
+		row, ok := rows[schedule]
+		if !ok {
+			row = &notifications.DigestSchedule{Schedule: schedule}
+			rows[schedule] = row
+		}
+
+		switch phase {
+		case "Completed":
+			row.Completed++
+		case "PartiallyFailed":
+			row.PartiallyFailed++
+		case "Failed":
+			row.Failed++
+		}
+
+		if started := backupStartTime(item.Object); !started.IsZero() {
+			duration := completed.Sub(started)
+			row.TotalDuration += duration
+			if duration > row.SlowestDuration {
+				row.Slowest, row.SlowestDuration = item.GetName(), duration
+			}
+		}
+
+		if isFailedPhase(phase) && completed.After(lastFailures[schedule]) {
+			lastFailures[schedule] = completed
+			row.LastFailure = item.GetName() + ": " + failureSummary(item.Object, phase)
+		}
+	}
+
+	digest := &notifications.Digest{Name: name, Since: since, Until: until}
+	for _, row := range rows {
+		digest.Schedules = append(digest.Schedules, *row)
+	}
+	sort.Slice(digest.Schedules, func(i, j int) bool {
+		a, b := digest.Schedules[i].Schedule, digest.Schedules[j].Schedule
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return a < b
+	})
+
+	return digest, nil
+}
+
+func failureSummary(obj map[string]interface{}, phase string) string {
+	if reason, _, _ := unstructured.NestedString(obj, "status", "failureReason"); reason != "" {
+		return reason
+	}
+	if errorsCount := extractErrors(obj); errorsCount > 0 {
+		return fmt.Sprintf("%s with %d errors", phase, errorsCount)
+	}
+	return phase
+}
+
+func digestEvent(digest *notifications.Digest) notifications.Event {
+	completed, partiallyFailed, failed := 0, 0, 0
+	for _, schedule := range digest.Schedules {
+		completed += schedule.Completed
+		partiallyFailed += schedule.PartiallyFailed
+		failed += schedule.Failed
+	}
+
+	period := fmt.Sprintf("between %s and %s", formatTime(digest.Since.Format(time.RFC3339)), formatTime(digest.Until.Format(time.RFC3339)))
+	message := fmt.Sprintf("Backup digest %s: no backups finished %s.", digest.Name, period)
+	if total := completed + partiallyFailed + failed; total > 0 {
+		message = fmt.Sprintf("Backup digest %s: %d backups finished %s.\n\nCompleted: %d\nPartially Failed: %d\nFailed: %d", digest.Name, total, period, completed, partiallyFailed, failed)
+	}
+
+	return notifications.Event{
+		Status:  "Digest",
+		Message: message,
+		Digest:  digest,
+	}
+}
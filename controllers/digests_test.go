@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckDigestsAggregatesBackupsPerSchedule(t *testing.T) {
+	t.Parallel()
+
+	finished := func(name, schedule, phase string, started time.Time, duration time.Duration, reason string) *unstructured.Unstructured {
+		obj := veleroObject("Backup", name, map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase":               phase,
+				"startTimestamp":      started.Format(time.RFC3339),
+				"completionTimestamp": started.Add(duration).Format(time.RFC3339),
+				"failureReason":       reason,
+			},
+		})
+		if schedule != "" {
+			obj.SetLabels(map[string]string{"velero.io/schedule-name": schedule})
+		}
+		return obj
+	}
+
+	day := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+	vc, recorder := newTestController(t,
+		finished("nightly-1", "nightly", "Completed", day.Add(time.Hour), 20*time.Minute, ""),
+		finished("nightly-2", "nightly", "Failed", day.Add(2*time.Hour), 50*time.Minute, "timed out"),
+		finished("weekly-1", "weekly", "PartiallyFailed", day.Add(3*time.Hour), time.Hour, ""),
+		finished("manual-1", "", "Completed", day.Add(4*time.Hour), time.Minute, ""),
+		finished("old-1", "nightly", "Failed", day.Add(-48*time.Hour), time.Minute, "too old"),
+	)
+
+	schedule, err := cron.ParseStandard("0 8 * * *")
+	if err != nil {
+		t.Fatalf("parse schedule: %v", err)
+	}
+	vc.Digests = []DigestOptions{{Name: "daily", Schedule: schedule}}
+
+	vc.checkDigests(day.Add(-time.Hour))
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected no digest before its run time, got %v", recorder.statuses())
+	}
+
+	vc.checkDigests(day.Add(8*time.Hour + time.Minute))
+	vc.checkDigests(day.Add(8*time.Hour + 2*time.Minute))
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Digest"}) {
+		t.Fatalf("expected a single digest, got %v", got)
+	}
+
+	event := recorder.events[0]
+	if !strings.Contains(event.Message, "4 backups finished") || !strings.Contains(event.Message, "Failed: 1") {
+		t.Fatalf("unexpected digest message %q", event.Message)
+	}
+
+	rows := event.Digest.Schedules
+	if len(rows) != 3 || rows[0].Schedule != "nightly" || rows[2].Schedule != "" {
+		t.Fatalf("unexpected digest rows %+v", rows)
+	}
+	if nightly := rows[0]; nightly.Completed != 1 || nightly.Failed != 1 || nightly.TotalDuration != 70*time.Minute || nightly.Slowest != "nightly-2" || nightly.LastFailure != "nightly-2: timed out" {
+		t.Fatalf("unexpected nightly row %+v", nightly)
+	}
+	if weekly := rows[1]; weekly.LastFailure != "weekly-1: PartiallyFailed" {
+		t.Fatalf("unexpected weekly row %+v", weekly)
+	}
+}
@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+const (
+	DownloadTargetBackupLog     = "BackupLog"
+	DownloadTargetBackupResults = "BackupResults"
+
+	downloadPollInterval = time.Second
+	// maxDownloadSize caps both a downloaded file and its decompressed
+	// content, so a huge log cannot exhaust the memory of the pod.
+	maxDownloadSize = 64 << 20
+	// downloadShutdownTimeout bounds how long Run waits for the
+	// notifications still fetching logs or results on shutdown.
+	downloadShutdownTimeout = 10 * time.Second
+
+	truncatedLogMarker = "[velero-notifications: log truncated, only its tail is kept]\n"
+)
+
+var downloadRequestsGVR = schema.GroupVersionResource{
+	Group:    "velero.io",
+	Version:  "v1",
+	Resource: "downloadrequests",
+}
+
+// BackupResultsOptions controls whether the per-namespace warnings and
+// errors of a backup are downloaded and included in notifications.
+type BackupResultsOptions struct {
+	Enabled bool
+	Timeout time.Duration
+}
+
+// BackupLogsOptions controls how backup logs are fetched and attached to
+// failure notifications.
+type BackupLogsOptions struct {
+	Enabled bool
+	// MaxAttachmentSize caps the gzip'd log attached to emails, in bytes.
+	MaxAttachmentSize int
+	// MaxErrorLines is the number of error lines included inline.
+	MaxErrorLines int
+	Timeout       time.Duration
+}
+
+// downloadBackupFile asks Velero for a signed URL through a DownloadRequest
+// and returns the (gzip'd) content stored in the object store.
+func (vc *VeleroController) downloadBackupFile(ctx context.Context, backupName, kind string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "velero.io/v1",
+		"kind":       "DownloadRequest",
+		"metadata": map[string]interface{}{
+			"generateName": backupName + "-",
+			"namespace":    vc.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"target": map[string]interface{}{
+				"kind": kind,
+				"name": backupName,
+			},
+		},
+	}}
+
+	client := vc.dynClient.Resource(downloadRequestsGVR).Namespace(vc.Namespace)
+
+	created, err := client.Create(ctx, request, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("create download request: %w", err)
+	}
+
+	defer func() {
+		if err := client.Delete(context.Background(), created.GetName(), metav1.DeleteOptions{}); err != nil && vc.Verbose {
+			log.Printf("Failed to delete download request %s: %v", created.GetName(), err)
+		}
+	}()
+
+	var downloadURL string
+	for downloadURL == "" {
+		current, err := client.Get(ctx, created.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("get download request: %w", err)
+		}
+
+		phase, _, _ := unstructured.NestedString(current.Object, "status", "phase")
+		if phase == "Processed" {
+			downloadURL, _, _ = unstructured.NestedString(current.Object, "status", "downloadURL")
+			if downloadURL == "" {
+				return nil, fmt.Errorf("download request %s processed without URL", created.GetName())
+			}
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for download request %s: %w", created.GetName(), ctx.Err())
+		case <-time.After(downloadPollInterval):
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build download request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: unexpected status %d", kind, resp.StatusCode)
+	}
+
+	return readLimited(resp.Body, kind)
+}
+
+// readLimited reads at most maxDownloadSize bytes and fails beyond that.
+func readLimited(reader io.Reader, what string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(reader, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", what, maxDownloadSize)
+	}
+	return data, nil
+}
+
+// collectBackupLogs downloads the backup log and returns its first error
+// lines along with a size-capped gzip'd copy suitable for an attachment.
+func (vc *VeleroController) collectBackupLogs(ctx context.Context, backupName string) ([]string, *notifications.Attachment, error) {
+	compressed, err := vc.downloadBackupFile(ctx, backupName, DownloadTargetBackupLog, vc.BackupLogs.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := gunzip(compressed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decompress backup log: %w", err)
+	}
+
+	excerpt := extractErrorLines(raw, vc.BackupLogs.MaxErrorLines)
+
+	filename := backupName + "-logs.gz"
+	if limit := vc.BackupLogs.MaxAttachmentSize; limit > 0 && len(compressed) > limit {
+		if compressed, err = truncateLog(raw, compressed, limit); err != nil {
+			return excerpt, nil, fmt.Errorf("compress backup log: %w", err)
+		}
+		filename = backupName + "-logs-truncated.gz"
+	}
+
+	return excerpt, &notifications.Attachment{
+		Filename:    filename,
+		ContentType: "application/gzip",
+		Data:        compressed,
+	}, nil
+}
+
+// notifyWithDownloads adds the backup results and logs to a backup event
+// and sends it. Failed downloads are logged and the event is sent without
+// them.
+func (vc *VeleroController) notifyWithDownloads(event notifications.Event, backupName string, fetchResults, fetchLogs bool) {
+	if fetchResults {
+		results, err := vc.collectBackupResults(context.TODO(), backupName)
+		if err != nil {
+			log.Printf("Failed to retrieve results for backup %s: %v", backupName, err)
+		}
+		event.Results = results
+	}
+	if fetchLogs {
+		excerpt, attachment, err := vc.collectBackupLogs(context.TODO(), backupName)
+		if err != nil {
+			log.Printf("Failed to retrieve logs for backup %s: %v", backupName, err)
+		}
+		event.LogExcerpt = excerpt
+		if attachment != nil {
+			event.Attachments = append(event.Attachments, *attachment)
+		}
+	}
+	vc.notifyAll(event)
+}
+
+// truncateLog keeps the tail of a log, which is where the failure usually
+// is, so that its gzip'd copy, marker line included, fits in limit bytes.
+// The cut is estimated from the compression ratio and repeated until the
+// compressed tail fits.
+func truncateLog(raw, compressed []byte, limit int) ([]byte, error) {
+	budget := max(limit-len(truncatedLogMarker), 0)
+	tail := raw
+	for len(compressed) > limit && len(tail) > 0 {
+		keep := min(len(tail)-1, int(int64(len(tail))*int64(budget)/int64(len(compressed))))
+		tail = tail[len(tail)-keep:]
+		if idx := bytes.IndexByte(tail, '\n'); idx >= 0 && idx < len(tail)-1 {
+			tail = tail[idx+1:]
+		}
+
+		var err error
+		if compressed, err = gzipBytes(append([]byte(truncatedLogMarker), tail...)); err != nil {
+			return nil, err
+		}
+	}
+	return compressed, nil
+}
+
+// collectBackupResults downloads the BackupResults file Velero writes next
+// to the backup and decodes its warnings and errors.
+func (vc *VeleroController) collectBackupResults(ctx context.Context, backupName string) (*notifications.BackupResults, error) {
+	compressed, err := vc.downloadBackupFile(ctx, backupName, DownloadTargetBackupResults, vc.BackupResults.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := gunzip(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompress backup results: %w", err)
+	}
+
+	return parseBackupResults(raw)
+}
+
+// veleroResult mirrors the Result type Velero serialises into the
+// BackupResults file.
+type veleroResult struct {
+	Velero     []string            `json:"velero,omitempty"`
+	Cluster    []string            `json:"cluster,omitempty"`
+	Namespaces map[string][]string `json:"namespaces,omitempty"`
+}
+
+func parseBackupResults(raw []byte) (*notifications.BackupResults, error) {
+	var decoded struct {
+		Errors   veleroResult `json:"errors"`
+		Warnings veleroResult `json:"warnings"`
+	}
+
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("decode backup results: %w", err)
+	}
+
+	return &notifications.BackupResults{
+		Errors:   groupResult(decoded.Errors),
+		Warnings: groupResult(decoded.Warnings),
+	}, nil
+}
+
+func groupResult(result veleroResult) map[string][]string {
+	grouped := make(map[string][]string, len(result.Namespaces)+2)
+	if len(result.Velero) > 0 {
+		grouped[notifications.ResultScopeVelero] = result.Velero
+	}
+	if len(result.Cluster) > 0 {
+		grouped[notifications.ResultScopeCluster] = result.Cluster
+	}
+	for namespace, messages := range result.Namespaces {
+		if len(messages) > 0 {
+			grouped[namespace] = messages
+		}
+	}
+	return grouped
+}
+
+func extractErrorLines(raw []byte, limit int) []string {
+	var lines []string
+	if limit <= 0 {
+		return lines
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "level=error") || strings.Contains(line, `"level":"error"`) {
+			lines = append(lines, line)
+			if len(lines) >= limit {
+				break
+			}
+		}
+	}
+
+	return lines
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readLimited(reader, "decompressed content")
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+func TestExtractErrorLines(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte(strings.Join([]string{
+		`time="2026-03-18T17:23:03Z" level=info msg="Setting up backup log"`,
+		`time="2026-03-18T17:23:04Z" level=error msg="Error backing up item" backup=velero/demo error="timed out"`,
+		`{"level":"error","msg":"pod volume backup failed"}`,
+		`time="2026-03-18T17:23:05Z" level=error msg="third error"`,
+	}, "\n"))
+
+	lines := extractErrorLines(raw, 2)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 error lines, got %d: %v", len(lines), lines)
+	}
+
+	if !strings.Contains(lines[0], "Error backing up item") || !strings.Contains(lines[1], "pod volume backup failed") {
+		t.Fatalf("unexpected error lines: %v", lines)
+	}
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	compressed, err := gzipBytes([]byte("level=error msg=boom\n"))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+
+	raw, err := gunzip(compressed)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+
+	if string(raw) != "level=error msg=boom\n" {
+		t.Fatalf("unexpected round trip result %q", raw)
+	}
+}
+
+func TestParseBackupResultsGroupsByNamespace(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte(`{
+		"errors": {"velero": ["plugin crashed"], "namespaces": {"shop": ["pod volume backup failed"]}},
+		"warnings": {"cluster": ["crd skipped"], "namespaces": {"shop": ["w1", "w2"], "empty": []}}
+	}`)
+
+	results, err := parseBackupResults(raw)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if got := results.Errors[notifications.ResultScopeVelero]; len(got) != 1 || got[0] != "plugin crashed" {
+		t.Fatalf("unexpected velero errors: %v", got)
+	}
+
+	if got := results.Errors["shop"]; len(got) != 1 {
+		t.Fatalf("unexpected shop errors: %v", got)
+	}
+
+	if got := results.Warnings[notifications.ResultScopeCluster]; len(got) != 1 {
+		t.Fatalf("unexpected cluster warnings: %v", got)
+	}
+
+	if _, ok := results.Warnings["empty"]; ok {
+		t.Fatal("expected namespaces without messages to be dropped")
+	}
+}
+
+func TestTruncateLogFitsTheCompressedLimit(t *testing.T) {
+	t.Parallel()
+
+	var raw []byte
+	for i := 0; i < 20000; i++ {
+		raw = append(raw, fmt.Sprintf("time=%d level=info msg=\"item %x backed up\"\n", i, i*7919)...)
+	}
+	compressed, err := gzipBytes(raw)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+
+	limit := len(compressed) / 4
+	truncated, err := truncateLog(raw, compressed, limit)
+	if err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	if len(truncated) > limit {
+		t.Fatalf("expected at most %d compressed bytes, got %d", limit, len(truncated))
+	}
+
+	tail, err := gunzip(truncated)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	if !bytes.HasPrefix(tail, []byte(truncatedLogMarker)) {
+		t.Fatalf("expected the truncation marker first, got %q", tail[:min(len(tail), 80)])
+	}
+	tail = bytes.TrimPrefix(tail, []byte(truncatedLogMarker))
+	if !bytes.HasSuffix(raw, tail) || !bytes.HasPrefix(tail, []byte("time=")) {
+		t.Fatalf("expected the tail of the log cut on a line boundary, got %q", tail[:min(len(tail), 40)])
+	}
+}
+
+func TestReadLimitedRejectsOversizedContent(t *testing.T) {
+	t.Parallel()
+
+	if _, err := readLimited(bytes.NewReader(make([]byte, maxDownloadSize+1)), "log"); err == nil {
+		t.Fatal("expected content over the cap to fail")
+	}
+	data, err := readLimited(bytes.NewReader(make([]byte, 10)), "log")
+	if err != nil || len(data) != 10 {
+		t.Fatalf("expected small content to be read, got %d bytes and %v", len(data), err)
+	}
+}
+
+func TestCheckBackupsDownloadsOffTheCheckLoop(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t)
+	vc.BackupLogs = BackupLogsOptions{Enabled: true, Timeout: 500 * time.Millisecond}
+
+	backup := veleroObject("Backup", "nightly-1", map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Failed"},
+	})
+	if _, err := vc.dynClient.Resource(backupsGVR).Namespace("velero").Create(context.TODO(), backup, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	vc.processedBackups["nightly-1"] = "InProgress"
+
+	started := time.Now()
+	vc.checkBackups()
+	if elapsed := time.Since(started); elapsed >= 500*time.Millisecond {
+		t.Fatalf("expected the check not to wait for the download, took %s", elapsed)
+	}
+
+	// The fake client never processes the DownloadRequest, so the event is
+	// sent without logs once the download times out.
+	vc.downloads.Wait()
+	if got := recorder.statuses(); len(got) != 1 || got[0] != "Failed" {
+		t.Fatalf("expected the failure to be sent, got %v", got)
+	}
+}
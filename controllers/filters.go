@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// FiltersOptions limits the backups and schedules the controller reports on.
+type FiltersOptions struct {
+	// LabelSelector is passed to the lists of backups and schedules.
+	LabelSelector string
+	Backups       NameFilter
+	// Schedules filters schedules and the backups they create.
+	Schedules NameFilter
+	// OnlyScheduled ignores the backups not created by a Schedule.
+	OnlyScheduled bool
+}
+
+// NameFilter keeps the names matching an include pattern, or every name when
+// there are none, unless they match an exclude pattern.
+type NameFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewNameFilter compiles include and exclude patterns. Patterns are globs
+// such as "test-*", or regular expressions when wrapped in slashes, such as
+// "/^adhoc-[0-9]+$/".
+func NewNameFilter(include, exclude []string) (NameFilter, error) {
+	var filter NameFilter
+	var err error
+	if filter.include, err = compileNamePatterns(include); err != nil {
+		return NameFilter{}, err
+	}
+	if filter.exclude, err = compileNamePatterns(exclude); err != nil {
+		return NameFilter{}, err
+	}
+	return filter, nil
+}
+
+func compileNamePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr := pattern
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expr = pattern[1 : len(pattern)-1]
+		} else {
+			expr = "^" + strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(pattern)) + "$"
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func (f NameFilter) allows(name string) bool {
+	for _, re := range f.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsBackup applies the backup filters, and the schedule filters to the
+// backups created by a schedule.
+func (o FiltersOptions) allowsBackup(backup *unstructured.Unstructured) bool {
+	schedule := backup.GetLabels()["velero.io/schedule-name"]
+	if schedule == "" {
+		return !o.OnlyScheduled && o.Backups.allows(backup.GetName())
+	}
+	return o.Backups.allows(backup.GetName()) && o.Schedules.allows(schedule)
+}
+
+// selects applies LabelSelector to an object that was not listed with it.
+// The selector is validated with the configuration, so a parse error
+// selects everything.
+func (o FiltersOptions) selects(obj *unstructured.Unstructured) bool {
+	selector, err := labels.Parse(o.LabelSelector)
+	return err != nil || selector.Matches(labels.Set(obj.GetLabels()))
+}
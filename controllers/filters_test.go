@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNameFilterGlobsAndRegexps(t *testing.T) {
+	t.Parallel()
+
+	filter, err := NewNameFilter([]string{"nightly-*", "/^weekly-[0-9]+$/"}, []string{"*-test"})
+	if err != nil {
+		t.Fatalf("new filter: %v", err)
+	}
+
+	for name, want := range map[string]bool{
+		"nightly-db":   true,
+		"weekly-42":    true,
+		"weekly-db":    false,
+		"nightly-test": false,
+		"adhoc":        false,
+	} {
+		if got := filter.allows(name); got != want {
+			t.Fatalf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	if _, err := NewNameFilter(nil, []string{"/(/"}); err == nil {
+		t.Fatal("expected an invalid regular expression to fail")
+	}
+}
+
+func TestCheckBackupsAppliesFilters(t *testing.T) {
+	t.Parallel()
+
+	backup := func(name, schedule string, labels map[string]string) *unstructured.Unstructured {
+		obj := veleroObject("Backup", name, map[string]interface{}{
+			"status": map[string]interface{}{"phase": "Failed"},
+		})
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		if schedule != "" {
+			labels["velero.io/schedule-name"] = schedule
+		}
+		obj.SetLabels(labels)
+		return obj
+	}
+
+	vc, recorder := newTestController(t,
+		backup("nightly-1", "nightly", map[string]string{"team": "a"}),
+		backup("adhoc-1", "", map[string]string{"team": "a"}),
+		backup("legacy-1", "legacy", map[string]string{"team": "a"}),
+		backup("other-1", "nightly", map[string]string{"team": "b"}),
+		backup("nightly-test", "nightly", map[string]string{"team": "a"}),
+	)
+
+	backups, _ := NewNameFilter(nil, []string{"*-test"})
+	schedules, _ := NewNameFilter(nil, []string{"legacy"})
+	vc.Filters = FiltersOptions{LabelSelector: "team=a", Backups: backups, Schedules: schedules, OnlyScheduled: true}
+	for _, name := range []string{"nightly-1", "adhoc-1", "legacy-1", "other-1", "nightly-test"} {
+		vc.processedBackups[name] = "InProgress"
+	}
+
+	vc.checkBackups()
+
+	var names []string
+	for _, event := range recorder.events {
+		names = append(names, event.BackupName)
+	}
+	if !reflect.DeepEqual(names, []string{"nightly-1"}) {
+		t.Fatalf("expected only nightly-1 to be reported, got %v", names)
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// HealthOptions controls the alert sent when the controller loses contact
+// with the Velero API.
+type HealthOptions struct {
+	// FailureThreshold is the number of consecutive failed checks before
+	// the alert opens.
+	FailureThreshold int
+}
+
+type healthState struct {
+	failures  int
+	down      bool
+	downSince time.Time
+}
+
+// checkVeleroCRDs reports Velero CRDs the API server does not serve. It runs
+// once at startup, as a missing CRD otherwise only shows as list errors.
+func (vc *VeleroController) checkVeleroCRDs() {
+	var missing []string
+	for _, gvr := range []schema.GroupVersionResource{backupsGVR, schedulesGVR} {
+		_, err := vc.dynClient.Resource(gvr).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{Limit: 1})
+		if apierrors.IsNotFound(err) {
+			missing = append(missing, gvr.Resource+"."+gvr.Group)
+		}
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Velero CRDs not found: %s. Is Velero installed in this cluster?", strings.Join(missing, ", "))
+	log.Println(message)
+	vc.health.down = true
+	vc.health.downSince = time.Now()
+	vc.notifyAll(notifications.Event{Status: "ContactLost", Message: message})
+}
+
+// recordAPIFailure counts a failed check and opens the alert once the
+// threshold is reached.
+func (vc *VeleroController) recordAPIFailure(err error) {
+	vc.health.failures++
+	if vc.health.down || vc.health.failures < max(vc.Health.FailureThreshold, 1) {
+		return
+	}
+
+	vc.health.down = true
+	vc.health.downSince = time.Now()
+	message := fmt.Sprintf("Lost contact with the Velero API after %d consecutive failed checks.\nLast Error: %v", vc.health.failures, err)
+	log.Println(message)
+	vc.notifyAll(notifications.Event{Status: "ContactLost", Message: message})
+}
+
+// recordAPISuccess resets the failure count and resolves an open alert.
+func (vc *VeleroController) recordAPISuccess() {
+	vc.health.failures = 0
+	if !vc.health.down {
+		return
+	}
+
+	vc.health.down = false
+	message := fmt.Sprintf("Contact with the Velero API restored after %s.", notifications.FormatDuration(time.Since(vc.health.downSince)))
+	log.Println(message)
+	vc.notifyAll(notifications.Event{Status: "ContactRestored", Message: message})
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckBackupsOpensAndResolvesContactAlert(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t)
+	vc.Health = HealthOptions{FailureThreshold: 3}
+
+	failing := true
+	vc.dynClient.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "backups", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if failing {
+			return true, nil, errors.New("connection reset by peer")
+		}
+		return false, nil, nil
+	})
+
+	for i := 0; i < 5; i++ {
+		vc.checkBackups()
+	}
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"ContactLost"}) {
+		t.Fatalf("expected a single alert after the threshold, got %v", got)
+	}
+	if message := recorder.events[0].Message; !strings.Contains(message, "after 3 consecutive failed checks") || !strings.Contains(message, "connection reset by peer") {
+		t.Fatalf("unexpected alert %q", message)
+	}
+
+	failing = false
+	vc.checkBackups()
+	vc.checkBackups()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"ContactLost", "ContactRestored"}) {
+		t.Fatalf("expected a single restored message, got %v", got)
+	}
+}
+
+func TestCheckVeleroCRDsReportsMissingCRDs(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t)
+	vc.dynClient.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "schedules", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schedulesGVR.GroupResource(), "")
+	})
+
+	vc.checkVeleroCRDs()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"ContactLost"}) {
+		t.Fatalf("expected a missing CRD alert, got %v", got)
+	}
+	if message := recorder.events[0].Message; !strings.Contains(message, "schedules.velero.io") || strings.Contains(message, "backups.velero.io") {
+		t.Fatalf("unexpected alert %q", message)
+	}
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Sources the cluster name can be detected from.
+const (
+	ClusterNameFromNamespaceUID = "kube-system-uid"
+	ClusterNameFromNodeLabel    = "node-label"
+	ClusterNameFromConfigMap    = "configmap"
+)
+
+var (
+	namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	nodesGVR      = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+)
+
+// ClusterDetection configures how the cluster name is detected when it is
+// not configured.
+type ClusterDetection struct {
+	Source string
+	// NodeLabel is read from the first node for ClusterNameFromNodeLabel.
+	NodeLabel string
+	// ConfigMapNamespace, ConfigMapName and ConfigMapKey locate the name for
+	// ClusterNameFromConfigMap.
+	ConfigMapNamespace string
+	ConfigMapName      string
+	ConfigMapKey       string
+}
+
+// DetectClusterName reads the cluster name from the cluster itself: the UID
+// of the kube-system namespace, a label of the nodes or a ConfigMap key.
+func (vc *VeleroController) DetectClusterName(detection ClusterDetection) (string, error) {
+	ctx := context.TODO()
+
+	switch detection.Source {
+	case ClusterNameFromNamespaceUID:
+		namespace, err := vc.dynClient.Resource(namespacesGVR).Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("get namespace %s: %w", metav1.NamespaceSystem, err)
+		}
+		return string(namespace.GetUID()), nil
+
+	case ClusterNameFromNodeLabel:
+		nodes, err := vc.dynClient.Resource(nodesGVR).List(ctx, metav1.ListOptions{LabelSelector: detection.NodeLabel, Limit: 1})
+		if err != nil {
+			return "", fmt.Errorf("list nodes: %w", err)
+		}
+		if len(nodes.Items) == 0 {
+			return "", fmt.Errorf("no node has the label %s", detection.NodeLabel)
+		}
+		name := nodes.Items[0].GetLabels()[detection.NodeLabel]
+		if name == "" {
+			return "", fmt.Errorf("label %s of node %s is empty", detection.NodeLabel, nodes.Items[0].GetName())
+		}
+		return name, nil
+
+	case ClusterNameFromConfigMap:
+		configMap, err := vc.dynClient.Resource(configMapsGVR).Namespace(detection.ConfigMapNamespace).Get(ctx, detection.ConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("get ConfigMap %s/%s: %w", detection.ConfigMapNamespace, detection.ConfigMapName, err)
+		}
+		name, found, _ := unstructured.NestedString(configMap.Object, "data", detection.ConfigMapKey)
+		if !found || name == "" {
+			return "", fmt.Errorf("ConfigMap %s/%s has no key %q", detection.ConfigMapNamespace, detection.ConfigMapName, detection.ConfigMapKey)
+		}
+		return name, nil
+	}
+
+	return "", fmt.Errorf("unknown cluster name source %q", detection.Source)
+}
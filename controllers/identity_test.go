@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func coreObject(kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+	}}
+	for key, value := range fields {
+		obj.Object[key] = value
+	}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestDetectClusterName(t *testing.T) {
+	t.Parallel()
+
+	kubeSystem := coreObject("Namespace", "", "kube-system", nil)
+	kubeSystem.SetUID("6f1c6d0e-3b1a-4c47-9d1e-2f0a3b1c2d3e")
+	node := coreObject("Node", "", "node-1", nil)
+	node.SetLabels(map[string]string{"example.com/cluster": "prod-eu"})
+	identity := coreObject("ConfigMap", "kube-system", "cluster-identity", map[string]interface{}{
+		"data": map[string]interface{}{"cluster-name": "prod-eu-1"},
+	})
+
+	vc, _ := newTestController(t, kubeSystem, node, identity)
+
+	cases := map[string]struct {
+		detection ClusterDetection
+		want      string
+	}{
+		"namespace uid": {ClusterDetection{Source: ClusterNameFromNamespaceUID}, "6f1c6d0e-3b1a-4c47-9d1e-2f0a3b1c2d3e"},
+		"node label":    {ClusterDetection{Source: ClusterNameFromNodeLabel, NodeLabel: "example.com/cluster"}, "prod-eu"},
+		"configmap": {ClusterDetection{
+			Source:             ClusterNameFromConfigMap,
+			ConfigMapNamespace: "kube-system",
+			ConfigMapName:      "cluster-identity",
+			ConfigMapKey:       "cluster-name",
+		}, "prod-eu-1"},
+	}
+	for name, tc := range cases {
+		got, err := vc.DetectClusterName(tc.detection)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", name, tc.want, got)
+		}
+	}
+
+	if _, err := vc.DetectClusterName(ClusterDetection{Source: ClusterNameFromNodeLabel, NodeLabel: "example.com/missing"}); err == nil {
+		t.Fatal("expected an error when no node has the label")
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AllNamespaces in the list passed to RunNamespaces watches every namespace
+// that holds a BackupStorageLocation.
+const AllNamespaces = "*"
+
+// ForNamespace returns a controller with the same options and client that
+// watches another namespace with its own state.
+func (vc *VeleroController) ForNamespace(namespace string) *VeleroController {
+	clone := *vc
+	clone.Namespace = namespace
+	clone.resetState()
+	clone.applyReconfigure()
+	return &clone
+}
+
+// RunNamespaces runs one controller per namespace until the context is done.
+// With AllNamespaces, the namespaces are discovered on every check, and
+// controllers are started for new Velero installations and stopped for
+// removed ones.
+func (vc *VeleroController) RunNamespaces(ctx context.Context, namespaces []string) {
+	if !slices.Contains(namespaces, AllNamespaces) {
+		for _, namespace := range namespaces {
+			go vc.ForNamespace(namespace).Run(ctx)
+		}
+		<-ctx.Done()
+		return
+	}
+
+	running := make(map[string]context.CancelFunc)
+	discover := func() {
+		discovered, err := vc.discoverNamespaces()
+		if err != nil {
+			log.Printf("Failed to discover Velero namespaces: %v", err)
+			return
+		}
+
+		for _, namespace := range discovered {
+			if _, ok := running[namespace]; ok {
+				continue
+			}
+			log.Printf("Watching Velero installation in namespace '%s'.", namespace)
+			namespaceCtx, cancel := context.WithCancel(ctx)
+			running[namespace] = cancel
+			go vc.ForNamespace(namespace).Run(namespaceCtx)
+		}
+
+		for namespace, cancel := range running {
+			if !slices.Contains(discovered, namespace) {
+				log.Printf("No BackupStorageLocation left in namespace '%s', stopping its controller.", namespace)
+				cancel()
+				delete(running, namespace)
+			}
+		}
+	}
+
+	discover()
+	ticker := time.NewTicker(vc.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			discover()
+		}
+	}
+}
+
+// discoverNamespaces returns the namespaces holding a BackupStorageLocation,
+// which every Velero installation needs to store backups.
+func (vc *VeleroController) discoverNamespaces() ([]string, error) {
+	list, err := vc.dynClient.Resource(storageLocationsGVR).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	for _, item := range list.Items {
+		if namespace := item.GetNamespace(); !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
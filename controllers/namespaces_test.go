@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func storageLocation(namespace, name string) *unstructured.Unstructured {
+	location := veleroObject("BackupStorageLocation", name, nil)
+	location.SetNamespace(namespace)
+	return location
+}
+
+func TestDiscoverNamespacesFindsVeleroInstallations(t *testing.T) {
+	t.Parallel()
+
+	vc, _ := newTestController(t,
+		storageLocation("velero-dr", "default"),
+		storageLocation("velero", "default"),
+		storageLocation("velero", "secondary"),
+	)
+
+	namespaces, err := vc.discoverNamespaces()
+	if err != nil {
+		t.Fatalf("discover namespaces: %v", err)
+	}
+	if want := []string{"velero", "velero-dr"}; !reflect.DeepEqual(namespaces, want) {
+		t.Fatalf("expected %v, got %v", want, namespaces)
+	}
+}
+
+func TestForNamespaceIsolatesStateAndTagsEvents(t *testing.T) {
+	t.Parallel()
+
+	backup := veleroObject("Backup", "nightly-1", map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Failed"},
+	})
+	backup.SetNamespace("velero-dr")
+
+	vc, recorder := newTestController(t, backup)
+	vc.Recoveries = RecoveriesOptions{Enabled: true, MinFailures: 1}
+	vc.processedBackups["nightly-1"] = "InProgress"
+
+	dr := vc.ForNamespace("velero-dr")
+	if dr.Recoveries != vc.Recoveries || dr.Router != vc.Router {
+		t.Fatal("expected the options to be shared")
+	}
+	if len(dr.processedBackups) != 0 {
+		t.Fatalf("expected a fresh state, got %v", dr.processedBackups)
+	}
+
+	dr.processedBackups["nightly-1"] = "InProgress"
+	dr.checkBackups()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Failed"}) {
+		t.Fatalf("expected the failure of velero-dr, got %v", got)
+	}
+	if namespace := recorder.events[0].VeleroNamespace; namespace != "velero-dr" {
+		t.Fatalf("expected the event to carry the Velero namespace, got %q", namespace)
+	}
+	if phase := vc.processedBackups["nightly-1"]; phase != "InProgress" {
+		t.Fatalf("expected the original controller state to be untouched, got %q", phase)
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// RecoveriesOptions controls the recovery notification sent when a schedule
+// succeeds again after a streak of failed backups.
+type RecoveriesOptions struct {
+	Enabled bool
+	// MinFailures is the length of the streak that makes a success a
+	// recovery.
+	MinFailures int
+}
+
+// scheduleStreak tracks the outcomes of a schedule since its last success.
+type scheduleStreak struct {
+	lastSuccess time.Time
+	// failures maps the failed backups completed after lastSuccess to their
+	// completion time, so recording the same backup twice is harmless.
+	failures map[string]time.Time
+}
+
+func isFailedPhase(phase string) bool {
+	return phase == "Failed" || phase == "PartiallyFailed"
+}
+
+// recordOutcome adds a finished scheduled backup to the streak of its
+// schedule and returns the streak it ended, if it was a clean success.
+func (vc *VeleroController) recordOutcome(obj map[string]interface{}, phase string) (int, time.Time) {
+	schedule, _, _ := unstructured.NestedString(obj, "metadata", "labels", "velero.io/schedule-name")
+	name, _, _ := unstructured.NestedString(obj, "metadata", "name")
+	completed := backupCompletionTime(obj)
+	if schedule == "" || completed.IsZero() || (phase != "Completed" && !isFailedPhase(phase)) {
+		return 0, time.Time{}
+	}
+
+	streak, ok := vc.streaks[schedule]
+	if !ok {
+		streak = &scheduleStreak{failures: make(map[string]time.Time)}
+		vc.streaks[schedule] = streak
+	}
+
+	if !completed.After(streak.lastSuccess) {
+		return 0, time.Time{}
+	}
+
+	if isFailedPhase(phase) {
+		streak.failures[name] = completed
+		return 0, time.Time{}
+	}
+
+	// A backup reported with incomplete snapshots is no clean success: the
+	// streak is kept for the next one to end with a recovery.
+	if vc.Snapshots.Enabled {
+		if _, incomplete := snapshotSummary(obj); incomplete {
+			return 0, time.Time{}
+		}
+	}
+
+	ended, previous := 0, streak.lastSuccess
+	for failed, at := range streak.failures {
+		if at.Before(completed) {
+			ended++
+			delete(streak.failures, failed)
+		}
+	}
+	streak.lastSuccess = completed
+	return ended, previous
+}
+
+// recoveryNote describes the streak a successful backup ended.
+func recoveryNote(failures int, previous, completed time.Time) string {
+	noun := "failures"
+	if failures == 1 {
+		noun = "failure"
+	}
+
+	if previous.IsZero() {
+		return fmt.Sprintf("Recovered after %d %s (no earlier success seen)", failures, noun)
+	}
+	return fmt.Sprintf("Recovered after %d %s (last success %s ago)", failures, noun, notifications.FormatDuration(completed.Sub(previous)))
+}
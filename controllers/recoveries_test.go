@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckBackupsReportsRecoveryAfterFailureStreak(t *testing.T) {
+	t.Parallel()
+
+	lastNight := time.Now().Add(-24 * time.Hour).UTC()
+	scheduled := func(name, phase string, completed time.Time) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "velero",
+				"labels":    map[string]interface{}{"velero.io/schedule-name": "nightly"},
+			},
+			"status": map[string]interface{}{
+				"phase":               phase,
+				"startTimestamp":      completed.Add(-time.Hour).Format(time.RFC3339),
+				"completionTimestamp": completed.Format(time.RFC3339),
+			},
+		}
+	}
+
+	vc, recorder := newTestController(t,
+		veleroObject("Backup", "nightly-1", scheduled("nightly-1", "Completed", lastNight.Add(-72*time.Hour))),
+		veleroObject("Backup", "nightly-2", scheduled("nightly-2", "Failed", lastNight.Add(-48*time.Hour))),
+		veleroObject("Backup", "nightly-3", scheduled("nightly-3", "PartiallyFailed", lastNight.Add(-24*time.Hour))),
+		veleroObject("Backup", "nightly-4", scheduled("nightly-4", "Failed", lastNight)),
+	)
+	vc.Recoveries = RecoveriesOptions{Enabled: true, MinFailures: 2}
+
+	vc.checkBackups()
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected existing backups to seed the streak silently, got %v", recorder.statuses())
+	}
+
+	client := vc.dynClient.Resource(backupsGVR).Namespace("velero")
+	running := veleroObject("Backup", "nightly-5", scheduled("nightly-5", "InProgress", time.Now().UTC()))
+	if _, err := client.Create(context.TODO(), running, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	vc.checkBackups()
+
+	// Incomplete snapshots are reported without ending the streak.
+	vc.Snapshots = SnapshotsOptions{Enabled: true}
+	status := running.Object["status"].(map[string]interface{})
+	status["phase"] = "Completed"
+	status["volumeSnapshotsAttempted"] = int64(2)
+	status["volumeSnapshotsCompleted"] = int64(1)
+	if _, err := client.Update(context.TODO(), running, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update backup: %v", err)
+	}
+	vc.checkBackups()
+
+	next := veleroObject("Backup", "nightly-6", scheduled("nightly-6", "InProgress", time.Now().Add(time.Minute).UTC()))
+	if _, err := client.Create(context.TODO(), next, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	vc.checkBackups()
+
+	next.Object["status"].(map[string]interface{})["phase"] = "Completed"
+	if _, err := client.Update(context.TODO(), next, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update backup: %v", err)
+	}
+	vc.checkBackups()
+	vc.checkBackups()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"IncompleteSnapshots", "Recovered"}) {
+		t.Fatalf("expected the incomplete snapshots then a single recovery, got %v", got)
+	}
+	if message := recorder.events[1].Message; !strings.Contains(message, "Recovered after 3 failures (last success 4d ago)") {
+		t.Fatalf("unexpected recovery message %q", message)
+	}
+}
+
+func TestRecoveryNoteWithoutEarlierSuccess(t *testing.T) {
+	t.Parallel()
+
+	if got := recoveryNote(1, time.Time{}, time.Now()); got != "Recovered after 1 failure (no earlier success seen)" {
+		t.Fatalf("unexpected note %q", got)
+	}
+}
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// RegressionsOptions controls the anomaly notifications sent when a
+// completed backup deviates from the rolling baseline of its schedule.
+type RegressionsOptions struct {
+	Enabled bool
+	// Window is the number of recent completed backups kept per schedule.
+	Window int
+	// MinSamples is the number of backups needed before comparing.
+	MinSamples int
+	// DurationRatio flags backups that take longer than the median
+	// duration multiplied by this ratio.
+	DurationRatio float64
+	// ItemsRatio flags backups whose total items fall below the median
+	// multiplied by this ratio.
+	ItemsRatio float64
+}
+
+type backupSample struct {
+	name      string
+	completed time.Time
+	duration  time.Duration
+	items     int64
+}
+
+type scheduleBaseline struct {
+	samples []backupSample
+	// recorded holds the names of the backups added, including those the
+	// window dropped since, so each backup is counted once.
+	recorded map[string]bool
+}
+
+func (b *scheduleBaseline) has(name string) bool {
+	return b.recorded[name]
+}
+
+// add inserts the sample in completion order and keeps the newest window.
+func (b *scheduleBaseline) add(sample backupSample, window int) {
+	if b.recorded == nil {
+		b.recorded = make(map[string]bool)
+	}
+	b.recorded[sample.name] = true
+	b.samples = append(b.samples, sample)
+	sort.Slice(b.samples, func(i, j int) bool {
+		return b.samples[i].completed.Before(b.samples[j].completed)
+	})
+	if window > 0 && len(b.samples) > window {
+		b.samples = b.samples[len(b.samples)-window:]
+	}
+}
+
+// forget drops the recorded names of the backups that no longer exist.
+func (b *scheduleBaseline) forget(seen map[string]bool) {
+	for name := range b.recorded {
+		if !seen[name] {
+			delete(b.recorded, name)
+		}
+	}
+}
+
+func (b *scheduleBaseline) medians() (time.Duration, int64) {
+	durations := make([]int64, 0, len(b.samples))
+	items := make([]int64, 0, len(b.samples))
+	for _, sample := range b.samples {
+		durations = append(durations, int64(sample.duration))
+		items = append(items, sample.items)
+	}
+	return time.Duration(median(durations)), median(items)
+}
+
+func median(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// sampleFromBackup extracts the schedule, duration and total items of a
+// completed scheduled backup.
+func sampleFromBackup(obj map[string]interface{}) (string, backupSample, bool) {
+	schedule, _, _ := unstructured.NestedString(obj, "metadata", "labels", "velero.io/schedule-name")
+	name, _, _ := unstructured.NestedString(obj, "metadata", "name")
+	started := backupStartTime(obj)
+	completed := backupCompletionTime(obj)
+	if schedule == "" || started.IsZero() || completed.IsZero() {
+		return "", backupSample{}, false
+	}
+
+	return schedule, backupSample{
+		name:      name,
+		completed: completed,
+		duration:  completed.Sub(started),
+		items:     nestedNumber(obj, "status", "progress", "totalItems"),
+	}, true
+}
+
+func (vc *VeleroController) baselineFor(schedule string) *scheduleBaseline {
+	baseline, ok := vc.baselines[schedule]
+	if !ok {
+		baseline = &scheduleBaseline{}
+		vc.baselines[schedule] = baseline
+	}
+	return baseline
+}
+
+// recordBaseline adds a completed backup the controller did not watch run,
+// which seeds the baselines from the backups that exist at startup.
+func (vc *VeleroController) recordBaseline(obj map[string]interface{}) {
+	schedule, sample, ok := sampleFromBackup(obj)
+	if !ok {
+		return
+	}
+
+	baseline := vc.baselineFor(schedule)
+	if !baseline.has(sample.name) {
+		baseline.add(sample, vc.Regressions.Window)
+	}
+}
+
+// checkRegression compares a newly completed backup with the baseline of its
+// schedule, notifies when it deviates, and then adds it to the baseline.
+func (vc *VeleroController) checkRegression(obj map[string]interface{}) {
+	schedule, sample, ok := sampleFromBackup(obj)
+	if !ok {
+		return
+	}
+
+	baseline := vc.baselineFor(schedule)
+	if baseline.has(sample.name) {
+		return
+	}
+
+	if len(baseline.samples) >= vc.Regressions.MinSamples {
+		usualDuration, usualItems := baseline.medians()
+		var findings []string
+
+		if vc.Regressions.DurationRatio > 0 && usualDuration > 0 {
+			ratio := float64(sample.duration) / float64(usualDuration)
+			if ratio >= vc.Regressions.DurationRatio {
+				findings = append(findings, fmt.Sprintf("Duration: %s, %.1fx the usual %s.", notifications.FormatDuration(sample.duration), ratio, notifications.FormatDuration(usualDuration)))
+			}
+		}
+
+		if vc.Regressions.ItemsRatio > 0 && usualItems > 0 {
+			ratio := float64(sample.items) / float64(usualItems)
+			if ratio <= vc.Regressions.ItemsRatio {
+				findings = append(findings, fmt.Sprintf("Total Items: %d, %.0f%% fewer than the usual %d.", sample.items, (1-ratio)*100, usualItems))
+			}
+		}
+
+		if len(findings) > 0 {
+			message := fmt.Sprintf("Backup %s deviates from the baseline of schedule %s.\n\n%s\n\nBaseline: median of the last %d completed backups.", sample.name, schedule, strings.Join(findings, "\n"), len(baseline.samples))
+			log.Println(message)
+			vc.notifyAll(vc.backupEvent(obj, "Anomaly", message))
+		}
+	}
+
+	baseline.add(sample, vc.Regressions.Window)
+}
+
+func nestedNumber(obj map[string]interface{}, fields ...string) int64 {
+	value, found, err := unstructured.NestedFieldNoCopy(obj, fields...)
+	if err != nil || !found {
+		return 0
+	}
+
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case string:
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
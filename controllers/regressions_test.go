@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func completedBackup(name string, completed time.Time, duration time.Duration, items int64) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   name,
+			"labels": map[string]interface{}{"velero.io/schedule-name": "nightly"},
+		},
+		"status": map[string]interface{}{
+			"phase":               "Completed",
+			"startTimestamp":      completed.Add(-duration).UTC().Format(time.RFC3339),
+			"completionTimestamp": completed.UTC().Format(time.RFC3339),
+			"progress":            map[string]interface{}{"totalItems": items, "itemsBackedUp": items},
+		},
+	}
+}
+
+func TestMedian(t *testing.T) {
+	t.Parallel()
+
+	if got := median([]int64{5, 1, 3}); got != 3 {
+		t.Fatalf("expected 3, got %d", got)
+	}
+	if got := median([]int64{4, 1, 3, 2}); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
+
+func TestCheckRegressionFlagsSlowAndSmallBackups(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t)
+	vc.Regressions = RegressionsOptions{Enabled: true, Window: 5, MinSamples: 3, DurationRatio: 3, ItemsRatio: 0.5}
+
+	base := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		vc.recordBaseline(completedBackup(fmt.Sprintf("nightly-%d", i), base.Add(time.Duration(i)*24*time.Hour), time.Hour, 1000))
+	}
+
+	if got := len(vc.baselines["nightly"].samples); got != 5 {
+		t.Fatalf("expected baseline capped to window, got %d samples", got)
+	}
+
+	vc.checkRegression(completedBackup("nightly-normal", base.Add(7*24*time.Hour), 70*time.Minute, 990))
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected no anomaly for a normal backup, got %v", recorder.statuses())
+	}
+
+	vc.checkRegression(completedBackup("nightly-slow", base.Add(8*24*time.Hour), 4*time.Hour, 120))
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Anomaly"}) {
+		t.Fatalf("expected one anomaly, got %v", got)
+	}
+
+	message := recorder.events[0].Message
+	for _, want := range []string{"Duration: 4h, 4.0x the usual 1h", "Total Items: 120, 88% fewer than the usual 1000"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("expected %q in anomaly message, got %q", want, message)
+		}
+	}
+
+	vc.checkRegression(completedBackup("nightly-slow", base.Add(8*24*time.Hour), 4*time.Hour, 120))
+	if len(recorder.events) != 1 {
+		t.Fatal("expected a backup to be evaluated only once")
+	}
+}
+
+func TestRecordBaselineCountsEachBackupOnce(t *testing.T) {
+	t.Parallel()
+
+	vc, _ := newTestController(t)
+	vc.Regressions = RegressionsOptions{Enabled: true, Window: 2}
+
+	now := time.Now()
+	backups := []map[string]interface{}{
+		completedBackup("nightly-1", now.Add(-72*time.Hour), time.Hour, 100),
+		completedBackup("nightly-2", now.Add(-48*time.Hour), time.Hour, 100),
+		completedBackup("nightly-3", now.Add(-24*time.Hour), time.Hour, 100),
+	}
+	for range 3 {
+		for _, backup := range backups {
+			vc.recordBaseline(backup)
+		}
+	}
+
+	var names []string
+	for _, sample := range vc.baselines["nightly"].samples {
+		names = append(names, sample.name)
+	}
+	if !reflect.DeepEqual(names, []string{"nightly-2", "nightly-3"}) {
+		t.Fatalf("expected the two newest backups once, got %v", names)
+	}
+
+	if !vc.baselines["nightly"].has("nightly-1") {
+		t.Fatal("expected the backup dropped by the window to stay recorded")
+	}
+
+	vc.baselines["nightly"].forget(map[string]bool{"nightly-2": true, "nightly-3": true})
+	if vc.baselines["nightly"].has("nightly-1") || !vc.baselines["nightly"].has("nightly-3") {
+		t.Fatal("expected only the deleted backup to be forgotten")
+	}
+}
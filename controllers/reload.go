@@ -0,0 +1,36 @@
+package controller
+
+import "sync"
+
+// reconfigurer hands new options to every controller cloned from the same
+// base. Each controller applies them from its own goroutine at the start of
+// its next check, so options are never changed while a check runs.
+type reconfigurer struct {
+	mu      sync.Mutex
+	version int
+	apply   func(*VeleroController)
+}
+
+// Reconfigure sets the options of the running controllers, and of the ones
+// started later for new namespaces or clusters, with apply. It only changes
+// options; the tracked state of each controller is kept.
+func (vc *VeleroController) Reconfigure(apply func(*VeleroController)) {
+	vc.reconfig.mu.Lock()
+	defer vc.reconfig.mu.Unlock()
+	vc.reconfig.version++
+	vc.reconfig.apply = apply
+}
+
+// applyReconfigure applies the options set by Reconfigure since the last
+// call.
+func (vc *VeleroController) applyReconfigure() {
+	vc.reconfig.mu.Lock()
+	version, apply := vc.reconfig.version, vc.reconfig.apply
+	vc.reconfig.mu.Unlock()
+
+	if version == vc.reconfigVersion || apply == nil {
+		return
+	}
+	apply(vc)
+	vc.reconfigVersion = version
+}
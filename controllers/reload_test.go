@@ -0,0 +1,34 @@
+package controller
+
+import "testing"
+
+func TestReconfigureReachesClonedControllers(t *testing.T) {
+	t.Parallel()
+
+	vc, _ := newTestController(t)
+	dr := vc.ForNamespace("velero-dr")
+
+	vc.Reconfigure(func(vc *VeleroController) {
+		vc.Recoveries = RecoveriesOptions{Enabled: true, MinFailures: 2}
+	})
+	if dr.Recoveries.Enabled {
+		t.Fatal("expected the options to wait for the next check")
+	}
+
+	dr.applyReconfigure()
+	if !dr.Recoveries.Enabled || dr.Recoveries.MinFailures != 2 {
+		t.Fatalf("expected the new options, got %+v", dr.Recoveries)
+	}
+
+	// Options changed afterwards are not overwritten by the same version.
+	dr.Recoveries.MinFailures = 5
+	dr.applyReconfigure()
+	if dr.Recoveries.MinFailures != 5 {
+		t.Fatal("expected a version to be applied once")
+	}
+
+	later := vc.ForNamespace("velero-backup")
+	if !later.Recoveries.Enabled {
+		t.Fatal("expected a controller started later to get the options")
+	}
+}
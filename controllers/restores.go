@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// isRunningRestorePhase reports whether a restore has not reached a final
+// phase yet.
+func isRunningRestorePhase(phase string) bool {
+	switch phase {
+	case "New", "InProgress", "WaitingForPluginOperations", "WaitingForPluginOperationsPartiallyFailed", "Finalizing", "FinalizingPartiallyFailed":
+		return true
+	}
+	return false
+}
+
+// checkRestores reports the restores that moved volume data once they
+// finish, with the per-PVC outcome of their DataDownloads and
+// PodVolumeRestores. The event carries the metadata of the restored backup,
+// so the backup filters, routes and overrides apply.
+func (vc *VeleroController) checkRestores() {
+	list, err := vc.dynClient.Resource(restoresGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to retrieve restores from Velero: %v", err)
+		return
+	}
+
+	seen := make(map[string]bool, len(list.Items))
+	for _, item := range list.Items {
+		restoreName := item.GetName()
+		backupName, _, _ := unstructured.NestedString(item.Object, "spec", "backupName")
+		if !vc.Filters.Backups.allows(backupName) {
+			continue
+		}
+		seen[restoreName] = true
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if _, tracked := vc.processedRestores[restoreName]; !tracked {
+			if phase == "" || isRunningRestorePhase(phase) {
+				vc.processedRestores[restoreName] = phase
+			}
+			continue
+		}
+		if phase == "" || isRunningRestorePhase(phase) {
+			vc.processedRestores[restoreName] = phase
+			continue
+		}
+		delete(vc.processedRestores, restoreName)
+
+		volumes, err := vc.collectVolumeRestores(context.TODO(), restoreName)
+		if err != nil {
+			log.Printf("Failed to retrieve volume restores for restore %s: %v", restoreName, err)
+		}
+		if len(volumes) == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("Restore %s of backup %s finished with status: %s.", restoreName, backupName, phase)
+		message += volumeBackupSummary(volumes)
+
+		event, ok := vc.restoreEvent(&item, backupName, phase, message)
+		if !ok {
+			continue
+		}
+		event.Volumes = volumes
+		log.Println(message)
+		vc.notifyAll(event)
+	}
+
+	for name := range vc.processedRestores {
+		if !seen[name] {
+			delete(vc.processedRestores, name)
+		}
+	}
+}
+
+// restoreEvent builds the event of a finished restore from the restored
+// backup, and reports false when the backup filters exclude it. A backup
+// that no longer exists is only matched on its name.
+func (vc *VeleroController) restoreEvent(restore *unstructured.Unstructured, backupName, phase, message string) (notifications.Event, bool) {
+	namespaces := includedNamespaces(restore.Object, "spec", "includedNamespaces")
+
+	backup, err := vc.dynClient.Resource(backupsGVR).Namespace(vc.Namespace).Get(context.TODO(), backupName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Printf("Failed to retrieve backup %s of restore %s: %v", backupName, restore.GetName(), err)
+		}
+		schedule, _, _ := unstructured.NestedString(restore.Object, "spec", "scheduleName")
+		return notifications.Event{
+			Status:     phase,
+			Phase:      phase,
+			Message:    message,
+			BackupName: backupName,
+			Schedule:   schedule,
+			Namespaces: namespaces,
+			Warnings:   extractWarnings(restore.Object),
+			Errors:     extractErrors(restore.Object),
+		}, vc.Filters.Backups.allows(backupName)
+	}
+
+	if !vc.Filters.allowsBackup(backup) || !vc.Filters.selects(backup) {
+		return notifications.Event{}, false
+	}
+	event := vc.backupEvent(backup.Object, phase, message)
+	event.Phase = phase
+	// The counts are those of the restore, and so are the namespaces when
+	// it restores only some of the backup.
+	event.Warnings = extractWarnings(restore.Object)
+	event.Errors = extractErrors(restore.Object)
+	if !slices.Equal(namespaces, []string{"*"}) {
+		event.Namespaces = namespaces
+	}
+	return event, true
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestCheckRestoresSummarizesVolumeRestores(t *testing.T) {
+	t.Parallel()
+
+	download := veleroObject("DataDownload", "restore-1-m3n4b", map[string]interface{}{
+		"spec": map[string]interface{}{"targetVolume": map[string]interface{}{"namespace": "shop", "pvc": "data"}},
+		"status": map[string]interface{}{
+			"phase":    "Completed",
+			"progress": map[string]interface{}{"bytesDone": int64(2 << 30), "totalBytes": int64(2 << 30)},
+		},
+	})
+	download.SetAPIVersion("velero.io/v2alpha1")
+	download.SetLabels(map[string]string{restoreNameLabel: "restore-1"})
+
+	podVolumeRestore := veleroObject("PodVolumeRestore", "restore-1-k8s7d", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"pod":    map[string]interface{}{"namespace": "db", "name": "pg-0"},
+			"volume": "pgdata",
+		},
+		"status": map[string]interface{}{
+			"phase":   "Failed",
+			"message": "error running kopia restore",
+		},
+	})
+	podVolumeRestore.SetLabels(map[string]string{restoreNameLabel: "restore-1"})
+
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "pg-0", "namespace": "db"},
+		"spec": map[string]interface{}{
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "pgdata", "persistentVolumeClaim": map[string]interface{}{"claimName": "pgdata-pg-0"}},
+			},
+		},
+	}}
+
+	restore := veleroObject("Restore", "restore-1", map[string]interface{}{
+		"spec":   map[string]interface{}{"backupName": "nightly-20260318"},
+		"status": map[string]interface{}{"phase": "InProgress"},
+	})
+
+	vc, recorder := newTestController(t, restore, download, podVolumeRestore, pod)
+	vc.VolumeBackups = VolumeBackupsOptions{Enabled: true}
+
+	vc.checkRestores()
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected no notification for a running restore, got %v", recorder.statuses())
+	}
+
+	client := vc.dynClient.Resource(restoresGVR).Namespace("velero")
+	_ = unstructured.SetNestedField(restore.Object, "PartiallyFailed", "status", "phase")
+	if _, err := client.Update(context.TODO(), restore, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update restore: %v", err)
+	}
+
+	vc.checkRestores()
+	if got := recorder.statuses(); len(got) != 1 || got[0] != "PartiallyFailed" {
+		t.Fatalf("expected the finished restore to be reported, got %v", got)
+	}
+
+	event := recorder.events[0]
+	if event.BackupName != "nightly-20260318" {
+		t.Fatalf("expected the event to name the restored backup, got %q", event.BackupName)
+	}
+	for _, want := range []string{"Restore restore-1 of backup nightly-20260318", "Failed Volumes: 1 of 2"} {
+		if !strings.Contains(event.Message, want) {
+			t.Fatalf("expected %q in message %q", want, event.Message)
+		}
+	}
+
+	pvcs := map[string]string{}
+	for _, volume := range event.Volumes {
+		pvcs[volume.Method] = volume.Namespace + "/" + volume.PVC
+	}
+	if pvcs["DataDownload"] != "shop/data" || pvcs["PodVolumeRestore"] != "db/pgdata-pg-0" {
+		t.Fatalf("expected the volumes keyed by PVC, got %+v", event.Volumes)
+	}
+
+	vc.checkRestores()
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected the restore to be reported once, got %v", recorder.statuses())
+	}
+}
+
+func TestCheckRestoresAppliesTheFiltersAndOverridesOfTheBackup(t *testing.T) {
+	t.Parallel()
+
+	restoreOf := func(name, backupName string) []runtime.Object {
+		restore := veleroObject("Restore", name, map[string]interface{}{
+			"spec":   map[string]interface{}{"backupName": backupName},
+			"status": map[string]interface{}{"phase": "Completed", "warnings": int64(2)},
+		})
+		restore.SetLabels(map[string]string{"team": "restores"})
+		podVolumeRestore := veleroObject("PodVolumeRestore", name+"-k8s7d", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"pod":    map[string]interface{}{"namespace": "db", "name": "pg-0"},
+				"volume": "pgdata",
+			},
+			"status": map[string]interface{}{"phase": "Completed"},
+		})
+		podVolumeRestore.SetLabels(map[string]string{restoreNameLabel: name})
+		return []runtime.Object{restore, podVolumeRestore}
+	}
+
+	nightly := veleroObject("Backup", "nightly-1", map[string]interface{}{
+		"spec": map[string]interface{}{"includedNamespaces": []interface{}{"db"}},
+	})
+	nightly.SetLabels(map[string]string{"velero.io/schedule-name": "nightly", "team": "dba"})
+	adhoc := veleroObject("Backup", "adhoc-1", nil)
+	adhoc.SetLabels(map[string]string{"team": "dba"})
+	other := veleroObject("Backup", "other-1", nil)
+	other.SetLabels(map[string]string{"velero.io/schedule-name": "nightly", "team": "web"})
+	schedule := veleroObject("Schedule", "nightly", nil)
+	schedule.SetAnnotations(map[string]string{MentionAnnotation: "@dba"})
+
+	objects := []runtime.Object{nightly, adhoc, other, schedule}
+	for _, restore := range []string{"nightly-1", "adhoc-1", "other-1"} {
+		objects = append(objects, restoreOf("restore-"+restore, restore)...)
+	}
+	vc, recorder := newTestController(t, objects...)
+	vc.VolumeBackups = VolumeBackupsOptions{Enabled: true}
+	vc.Filters.LabelSelector = "team=dba"
+	vc.Filters.OnlyScheduled = true
+	for _, restore := range []string{"nightly-1", "adhoc-1", "other-1"} {
+		vc.processedRestores["restore-"+restore] = "InProgress"
+	}
+
+	vc.checkRestores()
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected only the restore of the selected scheduled backup, got %+v", recorder.events)
+	}
+	event := recorder.events[0]
+	if event.BackupName != "nightly-1" || event.Schedule != "nightly" || event.Labels["team"] != "dba" {
+		t.Fatalf("expected the metadata of the backup, got %+v", event)
+	}
+	if event.Warnings != 2 || len(event.Overrides.Mentions) != 1 || event.Overrides.Mentions[0] != "@dba" {
+		t.Fatalf("expected the restore warnings and the schedule overrides, got %+v", event)
+	}
+}
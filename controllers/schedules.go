@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+var schedulesGVR = schema.GroupVersionResource{
+	Group:    "velero.io",
+	Version:  "v1",
+	Resource: "schedules",
+}
+
+// MissedSchedulesOptions controls the detection of schedules that did not
+// produce a backup when their cron expression said they should.
+type MissedSchedulesOptions struct {
+	Enabled     bool
+	GracePeriod time.Duration
+}
+
+type scheduleState struct {
+	phase  string
+	paused bool
+	// activeSince is when the schedule was last seen unpaused, so a resumed
+	// schedule is not reported for runs it skipped while paused.
+	activeSince time.Time
+	// missedRun is the expected run that has already been reported.
+	missedRun time.Time
+}
+
+func (vc *VeleroController) checkSchedules() {
+	list, err := vc.dynClient.Resource(schedulesGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: vc.Filters.LabelSelector})
+	if err != nil {
+		log.Printf("Failed to retrieving schedules from Velero: %v", err)
+		return
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(list.Items))
+
+	for _, item := range list.Items {
+		name := item.GetName()
+		if !vc.Filters.Schedules.allows(name) {
+			continue
+		}
+		seen[name] = true
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		paused, _, _ := unstructured.NestedBool(item.Object, "spec", "paused")
+
+		state, known := vc.schedules[name]
+		if !known {
+			state = &scheduleState{phase: phase, paused: paused}
+			vc.schedules[name] = state
+			if vc.Verbose {
+				log.Printf("Watching schedule %s (phase %s, paused %t).", name, phase, paused)
+			}
+			if phase == "FailedValidation" {
+				vc.notifyAll(scheduleValidationEvent(item.Object))
+			}
+		}
+
+		if phase != state.phase {
+			if phase == "FailedValidation" {
+				vc.notifyAll(scheduleValidationEvent(item.Object))
+			}
+			state.phase = phase
+		}
+
+		if paused != state.paused {
+			status, verb := "Paused", "paused"
+			if !paused {
+				status, verb = "Unpaused", "unpaused"
+				state.activeSince = now
+			}
+			message := fmt.Sprintf("Schedule %s was %s.", name, verb)
+			log.Println(message)
+			vc.notifyAll(scheduleEvent(item.Object, status, message))
+			state.paused = paused
+		}
+
+		if paused || phase != "Enabled" {
+			continue
+		}
+
+		cronSpec, _, _ := unstructured.NestedString(item.Object, "spec", "schedule")
+		lastBackup := scheduleLastBackup(item.Object)
+		reference := lastBackup
+		if reference.IsZero() {
+			reference = item.GetCreationTimestamp().Time
+		}
+		if state.activeSince.After(reference) {
+			reference = state.activeSince
+		}
+
+		expected, overdue, err := scheduleOverdue(cronSpec, reference, now, vc.MissedSchedules.GracePeriod)
+		if err != nil {
+			if vc.Verbose {
+				log.Printf("Unable to parse schedule %s expression %q: %v", name, cronSpec, err)
+			}
+			continue
+		}
+
+		if overdue && !expected.Equal(state.missedRun) {
+			last := "never"
+			if !lastBackup.IsZero() {
+				last = formatTime(lastBackup.Format(time.RFC3339))
+			}
+			message := fmt.Sprintf("Schedule %s missed its backup expected at %s.\n\nSchedule: %s\nLast Backup: %s", name, formatTime(expected.Format(time.RFC3339)), cronSpec, last)
+			log.Println(message)
+			vc.notifyAll(scheduleEvent(item.Object, "Missed", message))
+			state.missedRun = expected
+		}
+	}
+
+	for name := range vc.schedules {
+		if !seen[name] {
+			delete(vc.schedules, name)
+		}
+	}
+}
+
+// scheduleOverdue returns the run expected after reference and whether it
+// is late by more than the grace period.
+func scheduleOverdue(cronSpec string, reference, now time.Time, grace time.Duration) (time.Time, bool, error) {
+	parsed, err := cron.ParseStandard(strings.TrimSpace(cronSpec))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	expected := parsed.Next(reference)
+	return expected, now.After(expected.Add(grace)), nil
+}
+
+func scheduleLastBackup(obj map[string]interface{}) time.Time {
+	return nestedTime(obj, "status", "lastBackup")
+}
+
+func scheduleValidationEvent(obj map[string]interface{}) notifications.Event {
+	name, _, _ := unstructured.NestedString(obj, "metadata", "name")
+	validationErrors, _, _ := unstructured.NestedStringSlice(obj, "status", "validationErrors")
+
+	message := fmt.Sprintf("Schedule %s failed validation.", name)
+	if len(validationErrors) > 0 {
+		message += "\nFailure Reason: " + strings.Join(validationErrors, "; ")
+	}
+
+	log.Println(message)
+	return scheduleEvent(obj, "FailedValidation", message)
+}
+
+// scheduleEvent builds an event for a schedule, using the backup template
+// for the fields the router matches on.
+func scheduleEvent(obj map[string]interface{}, status, message string) notifications.Event {
+	schedule := unstructured.Unstructured{Object: obj}
+	storageLocation, _, _ := unstructured.NestedString(obj, "spec", "template", "storageLocation")
+
+	return notifications.Event{
+		Status:          status,
+		Message:         message,
+		Schedule:        schedule.GetName(),
+		Labels:          schedule.GetLabels(),
+		Namespaces:      includedNamespaces(obj, "spec", "template", "includedNamespaces"),
+		StorageLocation: storageLocation,
+		Overrides:       annotationOverrides("schedule", schedule.GetName(), schedule.GetAnnotations()),
+	}
+}
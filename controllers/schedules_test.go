@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestScheduleOverdue(t *testing.T) {
+	t.Parallel()
+
+	reference := time.Date(2026, 3, 18, 2, 0, 0, 0, time.UTC)
+
+	expected, overdue, err := scheduleOverdue("0 2 * * *", reference, reference.Add(24*time.Hour+30*time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("scheduleOverdue: %v", err)
+	}
+	if !expected.Equal(reference.Add(24*time.Hour)) || overdue {
+		t.Fatalf("expected next run within grace period, got %s overdue=%t", expected, overdue)
+	}
+
+	if _, overdue, _ := scheduleOverdue("0 2 * * *", reference, reference.Add(26*time.Hour), time.Hour); !overdue {
+		t.Fatal("expected schedule to be overdue after the grace period")
+	}
+
+	if _, overdue, _ := scheduleOverdue("@every 6h", reference, reference.Add(5*time.Hour), 0); overdue {
+		t.Fatal("expected @every descriptor to be supported")
+	}
+
+	if _, _, err := scheduleOverdue("not a cron", reference, reference, 0); err == nil {
+		t.Fatal("expected error for invalid cron expression")
+	}
+}
+
+func TestCheckSchedulesReportsMissedPausedAndValidation(t *testing.T) {
+	t.Parallel()
+
+	lastBackup := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	vc, recorder := newTestController(t,
+		veleroObject("Schedule", "nightly", map[string]interface{}{
+			"spec":   map[string]interface{}{"schedule": "0 2 * * *"},
+			"status": map[string]interface{}{"phase": "Enabled", "lastBackup": lastBackup},
+		}),
+		veleroObject("Schedule", "broken", map[string]interface{}{
+			"spec":   map[string]interface{}{"schedule": "bogus"},
+			"status": map[string]interface{}{"phase": "FailedValidation", "validationErrors": []interface{}{"invalid schedule"}},
+		}),
+	)
+	vc.MissedSchedules = MissedSchedulesOptions{Enabled: true, GracePeriod: time.Hour}
+
+	vc.checkSchedules()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"FailedValidation", "Missed"}) {
+		t.Fatalf("expected missed schedule and validation failure, got %v", got)
+	}
+
+	vc.checkSchedules()
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected missed schedule to be reported once, got %v", recorder.statuses())
+	}
+
+	client := vc.dynClient.Resource(schedulesGVR).Namespace("velero")
+	nightly, err := client.Get(context.TODO(), "nightly", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	if err := unstructured.SetNestedField(nightly.Object, true, "spec", "paused"); err != nil {
+		t.Fatalf("set paused: %v", err)
+	}
+	if _, err := client.Update(context.TODO(), nightly, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update schedule: %v", err)
+	}
+
+	vc.checkSchedules()
+	if got := recorder.statuses(); got[len(got)-1] != "Paused" {
+		t.Fatalf("expected paused notification, got %v", got)
+	}
+
+	if recorder.events[len(recorder.events)-1].Schedule != "nightly" {
+		t.Fatalf("expected schedule name on event, got %+v", recorder.events[len(recorder.events)-1])
+	}
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+const (
+	// SilenceAnnotation on a Schedule mutes its events until an RFC 3339
+	// time, or during a "start/end" interval of RFC 3339 times.
+	SilenceAnnotation = "velero-notifications/silence"
+
+	silencesConfigMapKey = "silences.yaml"
+)
+
+var configMapsGVR = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "configmaps",
+}
+
+// SilencesOptions controls where the controller reads silences from.
+type SilencesOptions struct {
+	Silencer *notifications.Silencer
+	// ConfigMap names a ConfigMap in the Velero namespace holding silences.
+	ConfigMap string
+	// SyncInterval is the time between two reads of the ConfigMap and the
+	// Schedule annotations. Zero reads them on every check.
+	SyncInterval time.Duration
+}
+
+// configMapSilence is an entry of the silences.yaml key of the ConfigMap.
+type configMapSilence struct {
+	ID               string            `yaml:"id"`
+	Schedules        []string          `yaml:"schedules"`
+	Labels           map[string]string `yaml:"labels"`
+	Namespaces       []string          `yaml:"namespaces"`
+	StorageLocations []string          `yaml:"storage_locations"`
+	Phases           []string          `yaml:"phases"`
+	StartsAt         string            `yaml:"starts_at"`
+	EndsAt           string            `yaml:"ends_at"`
+	Comment          string            `yaml:"comment"`
+}
+
+// syncSilences refreshes the silences defined in the cluster.
+func (vc *VeleroController) syncSilences() {
+	if silences, err := vc.scheduleSilences(); err != nil {
+		log.Printf("Failed to read schedule silences: %v", err)
+	} else {
+		vc.Silences.Silencer.Replace(notifications.SilenceSourceAnnotation, vc.silenceScope(), silences)
+	}
+
+	if vc.Silences.ConfigMap == "" {
+		return
+	}
+
+	if silences, err := vc.configMapSilences(); err != nil {
+		log.Printf("Failed to read silences from ConfigMap %s: %v", vc.Silences.ConfigMap, err)
+	} else {
+		vc.Silences.Silencer.Replace(notifications.SilenceSourceConfigMap, vc.silenceScope(), silences)
+	}
+}
+
+// silencesDue reports whether the silences should be read again, and if so
+// records the sync.
+func (vc *VeleroController) silencesDue(now time.Time) bool {
+	if !vc.silencesSynced.IsZero() && now.Sub(vc.silencesSynced) < vc.Silences.SyncInterval {
+		return false
+	}
+	vc.silencesSynced = now
+	return true
+}
+
+// validScopedSilence checks a silence read from the cluster before scoping
+// it to the installation, so one without matchers is rejected rather than
+// muting the whole installation.
+func (vc *VeleroController) validScopedSilence(silence notifications.Silence) (notifications.Silence, error) {
+	if err := notifications.ValidateSilence(silence); err != nil {
+		return notifications.Silence{}, err
+	}
+	silence.Match = vc.scopedMatch(silence.Match)
+	return silence, nil
+}
+
+// silenceScope identifies the installation the synced silences belong to.
+func (vc *VeleroController) silenceScope() string {
+	if vc.Cluster == "" {
+		return vc.Namespace
+	}
+	return vc.Cluster + "/" + vc.Namespace
+}
+
+// scopedMatch restricts a matcher read from the cluster to the events of the
+// installation it was read from.
+func (vc *VeleroController) scopedMatch(match notifications.RouteMatch) notifications.RouteMatch {
+	match.VeleroNamespaces = []string{vc.Namespace}
+	if vc.Cluster != "" {
+		match.Clusters = []string{vc.Cluster}
+	}
+	return match
+}
+
+func (vc *VeleroController) scheduleSilences() ([]notifications.Silence, error) {
+	list, err := vc.dynClient.Resource(schedulesGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var silences []notifications.Silence
+	for _, item := range list.Items {
+		value, ok := item.GetAnnotations()[SilenceAnnotation]
+		if !ok {
+			continue
+		}
+
+		startsAt, endsAt, err := parseSilenceInterval(value)
+		if err != nil {
+			log.Printf("Ignoring %s annotation of schedule %s: %v", SilenceAnnotation, item.GetName(), err)
+			continue
+		}
+
+		silence, err := vc.validScopedSilence(notifications.Silence{
+			ID:       "schedule-" + item.GetName(),
+			Match:    notifications.RouteMatch{Schedules: []string{item.GetName()}},
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+			Comment:  "annotation on schedule " + item.GetName(),
+		})
+		if err != nil {
+			log.Printf("Ignoring %s annotation of schedule %s: %v", SilenceAnnotation, item.GetName(), err)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	return silences, nil
+}
+
+// parseSilenceInterval reads "end" or "start/end" RFC 3339 times.
+func parseSilenceInterval(value string) (time.Time, time.Time, error) {
+	start, end, interval := strings.Cut(strings.TrimSpace(value), "/")
+	if !interval {
+		start, end = "", start
+	}
+
+	var startsAt time.Time
+	if start != "" {
+		parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(start))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start time: %w", err)
+		}
+		startsAt = parsed
+	}
+
+	endsAt, err := time.Parse(time.RFC3339, strings.TrimSpace(end))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end time: %w", err)
+	}
+	return startsAt, endsAt, nil
+}
+
+func (vc *VeleroController) configMapSilences() ([]notifications.Silence, error) {
+	configMap, err := vc.dynClient.Resource(configMapsGVR).Namespace(vc.Namespace).Get(context.TODO(), vc.Silences.ConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, _, _ := unstructured.NestedString(configMap.Object, "data", silencesConfigMapKey)
+	var entries []configMapSilence
+	if err := yaml.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", silencesConfigMapKey, err)
+	}
+
+	silences := make([]notifications.Silence, 0, len(entries))
+	for i, entry := range entries {
+		interval := entry.EndsAt
+		if entry.StartsAt != "" {
+			interval = entry.StartsAt + "/" + entry.EndsAt
+		}
+		startsAt, endsAt, err := parseSilenceInterval(interval)
+		if err != nil {
+			log.Printf("Ignoring silence %d of ConfigMap %s: %v", i, vc.Silences.ConfigMap, err)
+			continue
+		}
+
+		silence, err := vc.validScopedSilence(notifications.Silence{
+			ID: entry.ID,
+			Match: notifications.RouteMatch{
+				Schedules:        entry.Schedules,
+				Labels:           entry.Labels,
+				Namespaces:       entry.Namespaces,
+				StorageLocations: entry.StorageLocations,
+				Phases:           entry.Phases,
+			},
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+			Comment:  entry.Comment,
+		})
+		if err != nil {
+			log.Printf("Ignoring silence %d of ConfigMap %s: %v", i, vc.Silences.ConfigMap, err)
+			continue
+		}
+		silences = append(silences, silence)
+	}
+	return silences, nil
+}
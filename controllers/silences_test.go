@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+func TestParseSilenceInterval(t *testing.T) {
+	t.Parallel()
+
+	startsAt, endsAt, err := parseSilenceInterval("2024-06-01T22:00:00Z/2024-06-02T02:00:00Z")
+	if err != nil {
+		t.Fatalf("parse interval: %v", err)
+	}
+	if !startsAt.Equal(time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)) || !endsAt.Equal(time.Date(2024, 6, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected interval %s/%s", startsAt, endsAt)
+	}
+
+	startsAt, endsAt, err = parseSilenceInterval(" 2024-06-02T02:00:00+02:00 ")
+	if err != nil {
+		t.Fatalf("parse end time: %v", err)
+	}
+	if !startsAt.IsZero() || !endsAt.Equal(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected interval %s/%s", startsAt, endsAt)
+	}
+
+	if _, _, err := parseSilenceInterval("tomorrow"); err == nil {
+		t.Fatal("expected an invalid end time to fail")
+	}
+}
+
+func TestSyncSilencesMutesAnnotatedSchedulesAndConfigMapEntries(t *testing.T) {
+	t.Parallel()
+
+	endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	schedule := veleroObject("Schedule", "nightly", nil)
+	schedule.SetAnnotations(map[string]string{SilenceAnnotation: endsAt})
+	invalid := veleroObject("Schedule", "hourly", nil)
+	invalid.SetAnnotations(map[string]string{SilenceAnnotation: "soon"})
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "silences", "namespace": "velero"},
+		"data": map[string]interface{}{
+			silencesConfigMapKey: "- namespaces: [\"postgres-*\"]\n  phases: [\"PartiallyFailed\"]\n  ends_at: \"" + endsAt + "\"\n  comment: flaky volume\n",
+		},
+	}}
+
+	vc, recorder := newTestController(t, schedule, invalid, configMap)
+	silencer := notifications.NewSilencer(nil)
+	vc.Router.SetSilencer(silencer)
+	vc.Silences = SilencesOptions{Silencer: silencer, ConfigMap: "silences"}
+
+	vc.syncSilences()
+
+	if silences := silencer.List(); len(silences) != 2 {
+		t.Fatalf("expected an annotation and a ConfigMap silence, got %+v", silences)
+	}
+
+	vc.notifyAll(notifications.Event{Status: "Failed", Schedule: "nightly"})
+	vc.notifyAll(notifications.Event{Status: "Failed", Schedule: "hourly"})
+	vc.notifyAll(notifications.Event{Status: "PartiallyFailed", Namespaces: []string{"postgres-main"}})
+	vc.notifyAll(notifications.Event{Status: "Failed", Namespaces: []string{"postgres-main"}})
+
+	if got := recorder.statuses(); len(got) != 2 || recorder.events[0].Schedule != "hourly" || got[1] != "Failed" {
+		t.Fatalf("unexpected notifications %+v", recorder.events)
+	}
+}
+
+func TestSyncSilencesIsScopedToTheNamespace(t *testing.T) {
+	t.Parallel()
+
+	schedule := veleroObject("Schedule", "nightly", nil)
+	schedule.SetAnnotations(map[string]string{SilenceAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)})
+
+	vc, recorder := newTestController(t, schedule)
+	silencer := notifications.NewSilencer(nil)
+	vc.Router.SetSilencer(silencer)
+	vc.Silences = SilencesOptions{Silencer: silencer}
+	dr := vc.ForNamespace("velero-dr")
+
+	vc.syncSilences()
+	dr.syncSilences()
+
+	if silences := silencer.List(); len(silences) != 1 {
+		t.Fatalf("expected the silence of velero to survive the sync of velero-dr, got %+v", silences)
+	}
+
+	vc.notifyAll(notifications.Event{Status: "Failed", Schedule: "nightly"})
+	dr.notifyAll(notifications.Event{Status: "Failed", Schedule: "nightly"})
+
+	if len(recorder.events) != 1 || recorder.events[0].VeleroNamespace != "velero-dr" {
+		t.Fatalf("expected only the schedule of velero-dr to notify, got %+v", recorder.events)
+	}
+}
+
+func TestSyncSilencesIgnoresInvalidConfigMapEntries(t *testing.T) {
+	t.Parallel()
+
+	endsAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "silences", "namespace": "velero"},
+		"data": map[string]interface{}{
+			silencesConfigMapKey: "- ends_at: \"" + endsAt + "\"\n" +
+				"- schedules: [\"nightly-[\"]\n  ends_at: \"" + endsAt + "\"\n" +
+				"- schedules: [\"nightly\"]\n  starts_at: \"" + endsAt + "\"\n  ends_at: \"2024-06-01T00:00:00Z\"\n" +
+				"- schedules: [\"hourly\"]\n  ends_at: \"" + endsAt + "\"\n",
+		},
+	}}
+
+	vc, recorder := newTestController(t, configMap)
+	silencer := notifications.NewSilencer(nil)
+	vc.Router.SetSilencer(silencer)
+	vc.Silences = SilencesOptions{Silencer: silencer, ConfigMap: "silences"}
+
+	vc.syncSilences()
+
+	if silences := silencer.List(); len(silences) != 1 {
+		t.Fatalf("expected only the valid silence, got %+v", silences)
+	}
+
+	vc.notifyAll(notifications.Event{Status: "Failed", Schedule: "nightly"})
+	vc.notifyAll(notifications.Event{Status: "Failed", Schedule: "hourly"})
+
+	if len(recorder.events) != 1 || recorder.events[0].Schedule != "nightly" {
+		t.Fatalf("expected only the nightly schedule to notify, got %+v", recorder.events)
+	}
+}
+
+func TestSilencesDueWaitsForTheSyncInterval(t *testing.T) {
+	t.Parallel()
+
+	vc, _ := newTestController(t)
+	vc.Silences = SilencesOptions{SyncInterval: time.Minute}
+	now := time.Now()
+
+	if !vc.silencesDue(now) {
+		t.Fatal("expected the first check to sync")
+	}
+	if vc.silencesDue(now.Add(30 * time.Second)) {
+		t.Fatal("expected no sync before the interval elapsed")
+	}
+	if !vc.silencesDue(now.Add(time.Minute)) {
+		t.Fatal("expected a sync once the interval elapsed")
+	}
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+const (
+	DownloadTargetBackupVolumeInfos = "BackupVolumeInfos"
+
+	dataMoverSuffix = " (data mover)"
+)
+
+// SnapshotsOptions controls the reporting of volume snapshot outcomes.
+type SnapshotsOptions struct {
+	Enabled bool
+	// VolumeDetails downloads the volume info of backups with incomplete
+	// snapshots to list the outcome of each volume.
+	VolumeDetails bool
+	Timeout       time.Duration
+}
+
+type snapshotCounts struct {
+	attempted int64
+	completed int64
+}
+
+func (c snapshotCounts) incomplete() bool {
+	return c.completed < c.attempted
+}
+
+// backupSnapshotCounts returns the native and CSI snapshot counters of a
+// backup status.
+func backupSnapshotCounts(obj map[string]interface{}) (snapshotCounts, snapshotCounts) {
+	native := snapshotCounts{
+		attempted: nestedNumber(obj, "status", "volumeSnapshotsAttempted"),
+		completed: nestedNumber(obj, "status", "volumeSnapshotsCompleted"),
+	}
+	csi := snapshotCounts{
+		attempted: nestedNumber(obj, "status", "csiVolumeSnapshotsAttempted"),
+		completed: nestedNumber(obj, "status", "csiVolumeSnapshotsCompleted"),
+	}
+	return native, csi
+}
+
+// snapshotSummary renders the snapshot counters of a backup and reports
+// whether some attempted snapshots did not complete.
+func snapshotSummary(obj map[string]interface{}) (string, bool) {
+	native, csi := backupSnapshotCounts(obj)
+
+	summary := ""
+	if native.attempted > 0 {
+		summary += fmt.Sprintf("\nVolume Snapshots: %d/%d completed", native.completed, native.attempted)
+	}
+	if csi.attempted > 0 {
+		summary += fmt.Sprintf("\nCSI Snapshots: %d/%d completed", csi.completed, csi.attempted)
+	}
+
+	return summary, native.incomplete() || csi.incomplete()
+}
+
+// backupVolumeInfo is the subset of a BackupVolumeInfos entry that the
+// notifications use.
+type backupVolumeInfo struct {
+	PVCName            string `json:"pvcName"`
+	PVCNamespace       string `json:"pvcNamespace"`
+	PVName             string `json:"pvName"`
+	BackupMethod       string `json:"backupMethod"`
+	SnapshotDataMoved  bool   `json:"snapshotDataMoved"`
+	Skipped            bool   `json:"skipped"`
+	Result             string `json:"result"`
+	NativeSnapshotInfo *struct {
+		Phase string `json:"phase"`
+	} `json:"nativeSnapshotInfo"`
+	PVBInfo *struct {
+		Phase string `json:"phase"`
+	} `json:"pvbInfo"`
+	SnapshotDataMovementInfo *struct {
+		Phase string `json:"phase"`
+	} `json:"snapshotDataMovementInfo"`
+}
+
+// collectVolumeInfos downloads the BackupVolumeInfos file, which Velero
+// 1.13 and later write next to the backup.
+func (vc *VeleroController) collectVolumeInfos(ctx context.Context, backupName string) ([]notifications.VolumeStatus, error) {
+	compressed, err := vc.downloadBackupFile(ctx, backupName, DownloadTargetBackupVolumeInfos, vc.Snapshots.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := gunzip(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompress backup volume infos: %w", err)
+	}
+
+	return parseVolumeInfos(raw)
+}
+
+func parseVolumeInfos(raw []byte) ([]notifications.VolumeStatus, error) {
+	var infos []backupVolumeInfo
+	if err := json.Unmarshal(raw, &infos); err != nil {
+		return nil, fmt.Errorf("decode backup volume infos: %w", err)
+	}
+
+	volumes := make([]notifications.VolumeStatus, 0, len(infos))
+	for _, info := range infos {
+		name := info.PVCName
+		if name == "" {
+			name = info.PVName
+		}
+
+		method := info.BackupMethod
+		if info.SnapshotDataMoved {
+			method += dataMoverSuffix
+		}
+
+		phase := info.Result
+		switch {
+		case info.Skipped:
+			phase = "skipped"
+		case phase != "":
+		case info.SnapshotDataMovementInfo != nil && info.SnapshotDataMovementInfo.Phase != "":
+			phase = info.SnapshotDataMovementInfo.Phase
+		case info.NativeSnapshotInfo != nil && info.NativeSnapshotInfo.Phase != "":
+			phase = info.NativeSnapshotInfo.Phase
+		case info.PVBInfo != nil && info.PVBInfo.Phase != "":
+			phase = info.PVBInfo.Phase
+		default:
+			phase = "unknown"
+		}
+
+		volumes = append(volumes, notifications.VolumeStatus{
+			Namespace: info.PVCNamespace,
+			PVC:       name,
+			Method:    method,
+			Phase:     phase,
+		})
+	}
+
+	return volumes, nil
+}
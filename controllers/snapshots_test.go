@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+func TestCheckBackupsAlertsOnIncompleteSnapshots(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t, veleroObject("Backup", "nightly-20260318", map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":                       "Completed",
+			"startTimestamp":              "2026-03-18T01:00:00Z",
+			"completionTimestamp":         "2026-03-18T01:20:00Z",
+			"volumeSnapshotsAttempted":    int64(2),
+			"volumeSnapshotsCompleted":    int64(2),
+			"csiVolumeSnapshotsAttempted": int64(3),
+			"csiVolumeSnapshotsCompleted": int64(1),
+		},
+	}))
+	vc.Snapshots = SnapshotsOptions{Enabled: true}
+	vc.processedBackups["nightly-20260318"] = "InProgress"
+
+	vc.checkBackups()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"IncompleteSnapshots"}) {
+		t.Fatalf("expected an incomplete snapshots alert, got %v", got)
+	}
+
+	message := recorder.events[0].Message
+	for _, want := range []string{"completed with incomplete volume snapshots", "Volume Snapshots: 2/2 completed", "CSI Snapshots: 1/3 completed"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("expected %q in message %q", want, message)
+		}
+	}
+}
+
+func TestParseVolumeInfos(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte(`[
+		{"pvcName": "data", "pvcNamespace": "shop", "backupMethod": "CSISnapshot", "snapshotDataMoved": true, "result": "failed"},
+		{"pvName": "pv-logs", "backupMethod": "NativeSnapshot", "nativeSnapshotInfo": {"phase": "Completed"}},
+		{"pvcName": "tmp", "pvcNamespace": "shop", "backupMethod": "PodVolumeBackup", "skipped": true}
+	]`)
+
+	volumes, err := parseVolumeInfos(raw)
+	if err != nil {
+		t.Fatalf("parse volume infos: %v", err)
+	}
+
+	want := []notifications.VolumeStatus{
+		{Namespace: "shop", PVC: "data", Method: "CSISnapshot (data mover)", Phase: "failed"},
+		{PVC: "pv-logs", Method: "NativeSnapshot", Phase: "Completed"},
+		{Namespace: "shop", PVC: "tmp", Method: "PodVolumeBackup", Phase: "skipped"},
+	}
+	if !reflect.DeepEqual(volumes, want) {
+		t.Fatalf("unexpected volumes:\n got %+v\nwant %+v", volumes, want)
+	}
+}
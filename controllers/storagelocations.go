@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+var storageLocationsGVR = schema.GroupVersionResource{
+	Group:    "velero.io",
+	Version:  "v1",
+	Resource: "backupstoragelocations",
+}
+
+// StorageLocationsOptions controls the monitoring of BackupStorageLocation
+// availability.
+type StorageLocationsOptions struct {
+	Enabled bool
+}
+
+type storageLocationState struct {
+	phase    string
+	provider string
+	message  string
+}
+
+func (vc *VeleroController) checkStorageLocations() {
+	list, err := vc.dynClient.Resource(storageLocationsGVR).Namespace(vc.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to retrieving backup storage locations from Velero: %v", err)
+		return
+	}
+
+	seen := make(map[string]bool, len(list.Items))
+	for _, item := range list.Items {
+		name := item.GetName()
+		seen[name] = true
+
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		provider, _, _ := unstructured.NestedString(item.Object, "spec", "provider")
+		message, _, _ := unstructured.NestedString(item.Object, "status", "message")
+
+		previous, known := vc.storageLocations[name]
+		vc.storageLocations[name] = &storageLocationState{phase: phase, provider: provider, message: message}
+
+		switch {
+		case phase == "Unavailable" && (!known || previous.phase != "Unavailable"):
+			text := fmt.Sprintf("Backup storage location %s is unavailable.\n\nProvider: %s", name, provider)
+			if message != "" {
+				text += "\nFailure Reason: " + message
+			}
+			log.Println(text)
+			vc.notifyAll(storageLocationEvent(name, "Unavailable", text))
+		case phase == "Available" && known && previous.phase == "Unavailable":
+			text := fmt.Sprintf("Backup storage location %s is available again.\n\nProvider: %s", name, provider)
+			log.Println(text)
+			vc.notifyAll(storageLocationEvent(name, "Available", text))
+		}
+	}
+
+	for name := range vc.storageLocations {
+		if !seen[name] {
+			delete(vc.storageLocations, name)
+		}
+	}
+}
+
+// storageLocationNote describes the last known state of a backup storage
+// location, appended to backup failure notifications.
+func (vc *VeleroController) storageLocationNote(name string) string {
+	state, ok := vc.storageLocations[name]
+	if !ok || name == "" {
+		return ""
+	}
+
+	note := fmt.Sprintf("\nStorage Location: %s (%s) is %s", name, state.provider, state.phase)
+	if state.phase == "Unavailable" && state.message != "" {
+		note += ": " + state.message
+	}
+	return note
+}
+
+func storageLocationEvent(name, status, message string) notifications.Event {
+	return notifications.Event{
+		Status:          status,
+		Message:         message,
+		StorageLocation: name,
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckStorageLocationsNotifiesOnFlips(t *testing.T) {
+	t.Parallel()
+
+	vc, recorder := newTestController(t,
+		veleroObject("BackupStorageLocation", "default", map[string]interface{}{
+			"spec":   map[string]interface{}{"provider": "aws"},
+			"status": map[string]interface{}{"phase": "Available"},
+		}),
+	)
+
+	vc.checkStorageLocations()
+	if len(recorder.events) != 0 {
+		t.Fatalf("expected no notification for an available location, got %v", recorder.statuses())
+	}
+
+	setLocationStatus := func(phase, message string) {
+		t.Helper()
+		client := vc.dynClient.Resource(storageLocationsGVR).Namespace("velero")
+		location, err := client.Get(context.TODO(), "default", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get location: %v", err)
+		}
+		_ = unstructured.SetNestedField(location.Object, phase, "status", "phase")
+		_ = unstructured.SetNestedField(location.Object, message, "status", "message")
+		if _, err := client.Update(context.TODO(), location, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("update location: %v", err)
+		}
+	}
+
+	setLocationStatus("Unavailable", "NoSuchBucket: the bucket does not exist")
+	vc.checkStorageLocations()
+	vc.checkStorageLocations()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Unavailable"}) {
+		t.Fatalf("expected a single unavailable notification, got %v", got)
+	}
+
+	event := recorder.events[0]
+	if event.StorageLocation != "default" || !strings.Contains(event.Message, "Provider: aws") || !strings.Contains(event.Message, "NoSuchBucket") {
+		t.Fatalf("unexpected unavailable event %+v", event)
+	}
+
+	if note := vc.storageLocationNote("default"); note != "\nStorage Location: default (aws) is Unavailable: NoSuchBucket: the bucket does not exist" {
+		t.Fatalf("unexpected storage location note %q", note)
+	}
+
+	setLocationStatus("Available", "")
+	vc.checkStorageLocations()
+
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Unavailable", "Available"}) {
+		t.Fatalf("expected recovery notification, got %v", got)
+	}
+}
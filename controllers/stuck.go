@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+// StuckBackupsOptions controls the warning sent when a backup stays in a
+// running phase for longer than expected.
+type StuckBackupsOptions struct {
+	Enabled bool
+	// MaxDuration applies to backups whose schedule has no entry in
+	// Schedules, including ad hoc backups.
+	MaxDuration time.Duration
+	Schedules   map[string]time.Duration
+}
+
+func (o StuckBackupsOptions) threshold(schedule string) time.Duration {
+	if limit, ok := o.Schedules[schedule]; ok && schedule != "" {
+		return limit
+	}
+	return o.MaxDuration
+}
+
+func isRunningPhase(phase string) bool {
+	switch phase {
+	case "InProgress", "WaitingForPluginOperations", "WaitingForPluginOperationsPartiallyFailed", "Finalizing", "FinalizingPartiallyFailed":
+		return true
+	}
+	return false
+}
+
+// checkStuckBackup sends a single warning when a running backup exceeds the
+// maximum duration configured for its schedule.
+func (vc *VeleroController) checkStuckBackup(obj map[string]interface{}, backupName, phase string, now time.Time) {
+	if _, reported := vc.stuckBackups[backupName]; reported {
+		return
+	}
+
+	started := backupStartTime(obj)
+	if started.IsZero() {
+		return
+	}
+
+	schedule, _, _ := unstructured.NestedString(obj, "metadata", "labels", "velero.io/schedule-name")
+	limit := vc.StuckBackups.threshold(schedule)
+	if limit <= 0 {
+		return
+	}
+
+	running := now.Sub(started)
+	if running <= limit {
+		return
+	}
+
+	message := fmt.Sprintf("Backup %s is stuck in %s.\n\nStart Time: %s\nRunning For: %s\nExpected Within: %s", backupName, phase, formatTime(started.Format(time.RFC3339)), notifications.FormatDuration(running), notifications.FormatDuration(limit))
+	log.Println(message)
+	vc.notifyAll(vc.backupEvent(obj, "Stuck", message))
+	vc.stuckBackups[backupName] = now
+}
+
+// stuckFollowUp returns the note appended to the final notification of a
+// backup previously reported as stuck, and forgets it.
+func (vc *VeleroController) stuckFollowUp(obj map[string]interface{}, backupName string) string {
+	if _, reported := vc.stuckBackups[backupName]; !reported {
+		return ""
+	}
+	delete(vc.stuckBackups, backupName)
+
+	note := "\nPreviously reported as stuck"
+	if started := backupStartTime(obj); !started.IsZero() {
+		if completed := backupCompletionTime(obj); !completed.IsZero() {
+			note += fmt.Sprintf("; total duration: %s", notifications.FormatDuration(completed.Sub(started)))
+		}
+	}
+	return note + "."
+}
+
+func backupStartTime(obj map[string]interface{}) time.Time {
+	return nestedTime(obj, "status", "startTimestamp")
+}
+
+func backupCompletionTime(obj map[string]interface{}) time.Time {
+	return nestedTime(obj, "status", "completionTimestamp")
+}
+
+func nestedTime(obj map[string]interface{}, fields ...string) time.Time {
+	raw, found, err := unstructured.NestedString(obj, fields...)
+	if err != nil || !found {
+		return time.Time{}
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
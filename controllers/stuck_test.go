@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestStuckBackupsThresholdPerSchedule(t *testing.T) {
+	t.Parallel()
+
+	opts := StuckBackupsOptions{
+		MaxDuration: time.Hour,
+		Schedules:   map[string]time.Duration{"nightly-full": 8 * time.Hour},
+	}
+
+	if got := opts.threshold("nightly-full"); got != 8*time.Hour {
+		t.Fatalf("expected schedule override, got %s", got)
+	}
+
+	if got := opts.threshold(""); got != time.Hour {
+		t.Fatalf("expected default for ad hoc backups, got %s", got)
+	}
+}
+
+func TestCheckBackupsReportsStuckBackupOnceAndFollowsUp(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-5 * time.Hour).UTC()
+	vc, recorder := newTestController(t, veleroObject("Backup", "nightly-20260318", map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "nightly-20260318",
+			"namespace": "velero",
+			"labels":    map[string]interface{}{"velero.io/schedule-name": "nightly"},
+		},
+		"status": map[string]interface{}{
+			"phase":          "InProgress",
+			"startTimestamp": started.Format(time.RFC3339),
+		},
+	}))
+	vc.StuckBackups = StuckBackupsOptions{Enabled: true, MaxDuration: 4 * time.Hour}
+
+	vc.checkBackups()
+	vc.checkBackups()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Stuck"}) {
+		t.Fatalf("expected a single stuck warning, got %v", got)
+	}
+
+	client := vc.dynClient.Resource(backupsGVR).Namespace("velero")
+	backup, err := client.Get(context.TODO(), "nightly-20260318", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get backup: %v", err)
+	}
+	_ = unstructured.SetNestedField(backup.Object, "Completed", "status", "phase")
+	_ = unstructured.SetNestedField(backup.Object, time.Now().UTC().Format(time.RFC3339), "status", "completionTimestamp")
+	if _, err := client.Update(context.TODO(), backup, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update backup: %v", err)
+	}
+
+	vc.checkBackups()
+	if got := recorder.statuses(); !reflect.DeepEqual(got, []string{"Stuck", "Completed"}) {
+		t.Fatalf("expected completion follow-up, got %v", got)
+	}
+
+	if message := recorder.events[1].Message; !strings.Contains(message, "Previously reported as stuck; total duration: 5h") {
+		t.Fatalf("expected stuck note on completion, got %q", message)
+	}
+	if !recorder.events[1].FollowUp {
+		t.Fatal("expected the completion to be marked as a follow-up for failures_only receivers")
+	}
+}
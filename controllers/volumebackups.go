@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/zokeber/velero-notifications/notifications"
+)
+
+const (
+	backupNameLabel  = "velero.io/backup-name"
+	restoreNameLabel = "velero.io/restore-name"
+)
+
+var (
+	dataUploadsGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v2alpha1",
+		Resource: "datauploads",
+	}
+	dataDownloadsGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v2alpha1",
+		Resource: "datadownloads",
+	}
+	podVolumeBackupsGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v1",
+		Resource: "podvolumebackups",
+	}
+	podVolumeRestoresGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v1",
+		Resource: "podvolumerestores",
+	}
+	restoresGVR = schema.GroupVersionResource{
+		Group:    "velero.io",
+		Version:  "v1",
+		Resource: "restores",
+	}
+	podsGVR = schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+)
+
+// VolumeBackupsOptions controls whether the DataUploads and PodVolumeBackups
+// of a backup are summarized in its final notification, and the
+// DataDownloads and PodVolumeRestores of a restore in a notification about
+// the backup it restores.
+type VolumeBackupsOptions struct {
+	Enabled bool
+}
+
+// collectVolumeBackups lists the data mover uploads and file-system volume
+// backups created for a backup.
+func (vc *VeleroController) collectVolumeBackups(ctx context.Context, backupName string) ([]notifications.VolumeStatus, error) {
+	selector := metav1.ListOptions{LabelSelector: backupNameLabel + "=" + backupName}
+
+	var volumes []notifications.VolumeStatus
+	var errs []string
+
+	uploads, err := vc.dynClient.Resource(dataUploadsGVR).Namespace(vc.Namespace).List(ctx, selector)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list data uploads: %v", err))
+	} else {
+		for _, item := range uploads.Items {
+			volumes = append(volumes, dataUploadStatus(item.Object))
+		}
+	}
+
+	podVolumeBackups, err := vc.dynClient.Resource(podVolumeBackupsGVR).Namespace(vc.Namespace).List(ctx, selector)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list pod volume backups: %v", err))
+	} else {
+		for _, item := range podVolumeBackups.Items {
+			volumes = append(volumes, vc.podVolumeStatus(ctx, item.Object, "PodVolumeBackup"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return volumes, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return volumes, nil
+}
+
+func dataUploadStatus(obj map[string]interface{}) notifications.VolumeStatus {
+	namespace, _, _ := unstructured.NestedString(obj, "spec", "sourceNamespace")
+	pvc, _, _ := unstructured.NestedString(obj, "spec", "sourcePVC")
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj, "status", "message")
+
+	return notifications.VolumeStatus{
+		Namespace:  namespace,
+		PVC:        pvc,
+		Method:     "DataUpload",
+		Phase:      phase,
+		BytesDone:  nestedNumber(obj, "status", "progress", "bytesDone"),
+		BytesTotal: nestedNumber(obj, "status", "progress", "totalBytes"),
+		Message:    message,
+	}
+}
+
+// collectVolumeRestores lists the data mover downloads and file-system
+// volume restores created for a restore.
+func (vc *VeleroController) collectVolumeRestores(ctx context.Context, restoreName string) ([]notifications.VolumeStatus, error) {
+	selector := metav1.ListOptions{LabelSelector: restoreNameLabel + "=" + restoreName}
+
+	var volumes []notifications.VolumeStatus
+	var errs []string
+
+	downloads, err := vc.dynClient.Resource(dataDownloadsGVR).Namespace(vc.Namespace).List(ctx, selector)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list data downloads: %v", err))
+	} else {
+		for _, item := range downloads.Items {
+			volumes = append(volumes, dataDownloadStatus(item.Object))
+		}
+	}
+
+	podVolumeRestores, err := vc.dynClient.Resource(podVolumeRestoresGVR).Namespace(vc.Namespace).List(ctx, selector)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("list pod volume restores: %v", err))
+	} else {
+		for _, item := range podVolumeRestores.Items {
+			volumes = append(volumes, vc.podVolumeStatus(ctx, item.Object, "PodVolumeRestore"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return volumes, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return volumes, nil
+}
+
+func dataDownloadStatus(obj map[string]interface{}) notifications.VolumeStatus {
+	namespace, _, _ := unstructured.NestedString(obj, "spec", "targetVolume", "namespace")
+	pvc, _, _ := unstructured.NestedString(obj, "spec", "targetVolume", "pvc")
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj, "status", "message")
+
+	return notifications.VolumeStatus{
+		Namespace:  namespace,
+		PVC:        pvc,
+		Method:     "DataDownload",
+		Phase:      phase,
+		BytesDone:  nestedNumber(obj, "status", "progress", "bytesDone"),
+		BytesTotal: nestedNumber(obj, "status", "progress", "totalBytes"),
+		Message:    message,
+	}
+}
+
+// podVolumeStatus reports a PodVolumeBackup or PodVolumeRestore, which
+// record the pod and volume rather than the claim.
+func (vc *VeleroController) podVolumeStatus(ctx context.Context, obj map[string]interface{}, method string) notifications.VolumeStatus {
+	namespace, _, _ := unstructured.NestedString(obj, "spec", "pod", "namespace")
+	pod, _, _ := unstructured.NestedString(obj, "spec", "pod", "name")
+	volume, _, _ := unstructured.NestedString(obj, "spec", "volume")
+	phase, _, _ := unstructured.NestedString(obj, "status", "phase")
+	message, _, _ := unstructured.NestedString(obj, "status", "message")
+
+	return notifications.VolumeStatus{
+		Namespace:  namespace,
+		PVC:        vc.podVolumeClaim(ctx, namespace, pod, volume),
+		Method:     method,
+		Phase:      phase,
+		BytesDone:  nestedNumber(obj, "status", "progress", "bytesDone"),
+		BytesTotal: nestedNumber(obj, "status", "progress", "totalBytes"),
+		Message:    message,
+	}
+}
+
+// podVolumeClaim returns the name of the claim mounted as volume in the pod,
+// or "pod/volume" when the pod is gone or the volume is not a claim.
+func (vc *VeleroController) podVolumeClaim(ctx context.Context, namespace, pod, volume string) string {
+	fallback := pod + "/" + volume
+	obj, err := vc.dynClient.Resource(podsGVR).Namespace(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return fallback
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumes")
+	for _, raw := range volumes {
+		podVolume, ok := raw.(map[string]interface{})
+		if !ok || podVolume["name"] != volume {
+			continue
+		}
+		if claim, found, _ := unstructured.NestedString(podVolume, "persistentVolumeClaim", "claimName"); found && claim != "" {
+			return claim
+		}
+	}
+	return fallback
+}
+
+// volumeBackupSummary renders the aggregated progress of the volume backups.
+func volumeBackupSummary(volumes []notifications.VolumeStatus) string {
+	if len(volumes) == 0 {
+		return ""
+	}
+
+	var done, total int64
+	failed := 0
+	for _, volume := range volumes {
+		done += volume.BytesDone
+		total += volume.BytesTotal
+		if volume.Phase == "Failed" || volume.Phase == "Canceled" {
+			failed++
+		}
+	}
+
+	summary := fmt.Sprintf("\nVolume Data: %s/%s transferred", notifications.FormatBytes(done), notifications.FormatBytes(total))
+	if failed > 0 {
+		summary += fmt.Sprintf("\nFailed Volumes: %d of %d", failed, len(volumes))
+	}
+	return summary
+}
+
+// mergeVolumeInfos adds the snapshot outcomes from BackupVolumeInfos to the
+// tracked volume backups, skipping the volumes those already cover.
+func mergeVolumeInfos(tracked, infos []notifications.VolumeStatus) []notifications.VolumeStatus {
+	if len(tracked) == 0 {
+		return infos
+	}
+
+	merged := tracked
+	for _, info := range infos {
+		if info.Method == "PodVolumeBackup" || strings.HasSuffix(info.Method, dataMoverSuffix) {
+			continue
+		}
+		merged = append(merged, info)
+	}
+	return merged
+}
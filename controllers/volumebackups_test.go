@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckBackupsSummarizesVolumeBackups(t *testing.T) {
+	t.Parallel()
+
+	upload := veleroObject("DataUpload", "nightly-20260318-x7k2p", map[string]interface{}{
+		"spec": map[string]interface{}{"sourceNamespace": "shop", "sourcePVC": "data"},
+		"status": map[string]interface{}{
+			"phase":    "Completed",
+			"progress": map[string]interface{}{"bytesDone": int64(3 << 30), "totalBytes": int64(3 << 30)},
+		},
+	})
+	upload.SetAPIVersion("velero.io/v2alpha1")
+	upload.SetLabels(map[string]string{backupNameLabel: "nightly-20260318"})
+
+	podVolumeBackup := veleroObject("PodVolumeBackup", "nightly-20260318-q9w4z", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"pod":    map[string]interface{}{"namespace": "db", "name": "pg-0"},
+			"volume": "pgdata",
+		},
+		"status": map[string]interface{}{
+			"phase":    "Failed",
+			"message":  "error running kopia backup",
+			"progress": map[string]interface{}{"bytesDone": int64(1 << 30), "totalBytes": int64(2 << 30)},
+		},
+	})
+	podVolumeBackup.SetLabels(map[string]string{backupNameLabel: "nightly-20260318"})
+
+	other := veleroObject("PodVolumeBackup", "weekly-20260315-a1b2c", map[string]interface{}{
+		"status": map[string]interface{}{"phase": "Failed"},
+	})
+	other.SetLabels(map[string]string{backupNameLabel: "weekly-20260315"})
+
+	vc, recorder := newTestController(t,
+		veleroObject("Backup", "nightly-20260318", map[string]interface{}{
+			"status": map[string]interface{}{"phase": "PartiallyFailed"},
+		}),
+		upload, podVolumeBackup, other,
+	)
+	vc.VolumeBackups = VolumeBackupsOptions{Enabled: true}
+	vc.processedBackups["nightly-20260318"] = "InProgress"
+
+	vc.checkBackups()
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected one notification, got %v", recorder.statuses())
+	}
+
+	event := recorder.events[0]
+	for _, want := range []string{"Volume Data: 4.0 GiB/5.0 GiB transferred", "Failed Volumes: 1 of 2"} {
+		if !strings.Contains(event.Message, want) {
+			t.Fatalf("expected %q in message %q", want, event.Message)
+		}
+	}
+
+	if len(event.Volumes) != 2 {
+		t.Fatalf("expected the two volumes of the backup, got %+v", event.Volumes)
+	}
+
+	// The pod of the PodVolumeBackup is gone, so the volume is named after
+	// it.
+	for _, volume := range event.Volumes {
+		if volume.Method == "PodVolumeBackup" && volume.PVC != "pg-0/pgdata" {
+			t.Fatalf("expected the pod volume without its pod to fall back to pod/volume, got %q", volume.PVC)
+		}
+	}
+}
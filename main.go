@@ -3,9 +3,18 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/labels"
+
 	"github.com/zokeber/velero-notifications/config"
 	controller "github.com/zokeber/velero-notifications/controllers"
 	"github.com/zokeber/velero-notifications/notifications"
@@ -16,61 +25,476 @@ func main() {
 	configPath := flag.String("config", "config/config.yaml", "Path of config.yaml file")
 	flag.Parse()
 
+	redactor := config.NewRedactor(os.Stderr)
+	log.SetOutput(redactor)
+
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to retrieve the config.yaml file: %v", err)
 	}
 
-	var notifiers []notifications.Notifier
+	// The router is set once the secrets it needs are read through the
+	// controller's client.
+	veleroController, err := controller.NewVeleroController(
+		cfg.Namespace,
+		cfg.CheckInterval,
+		cfg.Logging.Verbose,
+		nil,
+	)
 
-	if cfg.Notifications.Slack.Enabled {
-		slackNotifier, err := notifications.NewSlackNotifier(notifications.SlackConfig{
-			Webhook:      cfg.Notifications.Slack.Webhook,
-			Channel:      cfg.Notifications.Slack.Channel,
-			Username:     cfg.Notifications.Slack.Username,
-			FailuresOnly: cfg.Notifications.Slack.FailuresOnly,
-			Prefix:       cfg.Notifications.NotificationPrefix,
-		})
-		if err != nil {
-			log.Printf("Failed to initialize Slack notifier: %v", err)
-		} else {
-			notifiers = append(notifiers, slackNotifier)
-		}
+	if err != nil {
+		log.Fatalf("Unable to initialize Velero Controller: %v", err)
+	}
+
+	if err := resolveSecrets(cfg, veleroController, redactor); err != nil {
+		log.Fatalf("Failed to read the notification secrets: %v", err)
 	}
 
-	if cfg.Notifications.Email.Enabled {
-		emailNotifier, err := notifications.NewEmailNotifier(notifications.EmailConfig{
-			SMTPServer:   cfg.Notifications.Email.SMTPServer,
-			SMTPPort:     cfg.Notifications.Email.SMTPPort,
-			Username:     cfg.Notifications.Email.Username,
-			Password:     cfg.Notifications.Email.Password,
-			From:         cfg.Notifications.Email.From,
-			To:           cfg.Notifications.Email.To,
-			FailuresOnly: cfg.Notifications.Email.FailuresOnly,
-			Prefix:       cfg.Notifications.NotificationPrefix,
+	router, windows, err := buildRouter(cfg, nil)
+	if err != nil {
+		log.Fatalf("Invalid notification settings: %v", err)
+	}
+	silencer := notifications.NewSilencer(windows)
+	router.SetSilencer(silencer)
+	veleroController.Router = router
+
+	veleroController.Cluster = cfg.ClusterName
+	if veleroController.Cluster == "" && cfg.ClusterDetection.Source != "" {
+		name, err := veleroController.DetectClusterName(controller.ClusterDetection{
+			Source:             cfg.ClusterDetection.Source,
+			NodeLabel:          cfg.ClusterDetection.NodeLabel,
+			ConfigMapNamespace: cfg.ClusterDetection.ConfigMap.Namespace,
+			ConfigMapName:      cfg.ClusterDetection.ConfigMap.Name,
+			ConfigMapKey:       cfg.ClusterDetection.ConfigMap.Key,
 		})
 		if err != nil {
-			log.Printf("Failed to initialize Email notifier: %v", err)
+			log.Printf("Failed to detect the cluster name: %v", err)
 		} else {
-			notifiers = append(notifiers, emailNotifier)
+			log.Printf("Detected cluster name %s.", name)
+			veleroController.Cluster = name
 		}
 	}
 
-	veleroController, err := controller.NewVeleroController(
-		cfg.Namespace,
-		cfg.CheckInterval,
-		cfg.Logging.Verbose,
-		notifiers,
-	)
-
+	applyOptions, err := controllerOptions(cfg, router, silencer)
 	if err != nil {
-		log.Fatalf("Unable to initialize Velero Controller: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	applyOptions(veleroController)
+
+	if cfg.Server.ListenAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		served := "metrics"
+		if cfg.Server.APIToken != "" {
+			silences := silencer.Handler(cfg.Server.APIToken)
+			mux.Handle("/api/v1/silences", silences)
+			mux.Handle("/api/v1/silences/", silences)
+			served = "metrics and the silences API"
+		} else {
+			log.Println("No server.api_token is set, so the silences API is disabled.")
+		}
+		go func() {
+			log.Printf("Serving %s on %s.", served, cfg.Server.ListenAddress)
+			if err := http.ListenAndServe(cfg.Server.ListenAddress, mux); err != nil {
+				log.Fatalf("HTTP server failed: %v", err)
+			}
+		}()
 	}
 
 	ctx := context.Background()
-	go veleroController.Run(ctx)
+	if cfg.Reload.Enabled {
+		failed := func(err error) {
+			log.Printf("Failed to reload %s, keeping the previous configuration: %v", *configPath, err)
+			if !cfg.Reload.NotifyOnFailure {
+				return
+			}
+			event := notifications.Event{
+				Status:  "ReloadFailed",
+				Message: fmt.Sprintf("Failed to reload the configuration, the previous one is still in use.\n\nError: %v", err),
+				Cluster: veleroController.Cluster,
+			}
+			if err := router.Dispatch(event); err != nil {
+				log.Printf("Error sending notifications: %v", err)
+			}
+		}
+		go config.Watch(ctx, *configPath, time.Duration(cfg.Reload.Interval)*time.Second,
+			reloader(cfg, router, silencer, veleroController, redactor, failed), failed)
+	}
+
+	if len(cfg.Clusters) > 0 {
+		go veleroController.RunClusters(ctx, toClusters(cfg.Clusters), cfg.Namespaces)
+	} else {
+		go veleroController.RunNamespaces(ctx, cfg.Namespaces)
+	}
 
 	<-ctx.Done()
 	log.Println("Exit")
 	time.Sleep(2 * time.Second)
 }
+
+// reloader returns the function applying a changed configuration. The
+// receivers, routes and maintenance windows are swapped in at once and the
+// controller options are picked up by every controller at its next check;
+// settings that shape the running controllers need a restart. A change that
+// cannot be applied, including an unreadable Secret, is reported to failed.
+func reloader(cfg *config.Config, router *notifications.Router, silencer *notifications.Silencer, vc *controller.VeleroController, redactor *config.Redactor, failed func(error)) func(*config.Config) {
+	current := cfg
+	return func(next *config.Config) {
+		if err := resolveSecrets(next, vc, redactor); err != nil {
+			failed(err)
+			return
+		}
+
+		// Unchanged receivers are kept with their throttle state.
+		previous := router.Receivers()
+		kept := make(map[string]notifications.Notifier)
+		for _, name := range unchangedReceivers(current, next) {
+			if notifier, ok := previous[name]; ok {
+				kept[name] = notifier
+			}
+		}
+		nextRouter, windows, err := buildRouter(next, kept)
+		if err != nil {
+			failed(err)
+			return
+		}
+		applyOptions, err := controllerOptions(next, nextRouter, silencer)
+		if err != nil {
+			failed(err)
+			return
+		}
+
+		for _, setting := range restartSettings(current, next) {
+			log.Printf("Ignoring the change of %s until the next restart.", setting)
+		}
+
+		router.Replace(nextRouter)
+		silencer.SetWindows(windows)
+		vc.Reconfigure(applyOptions)
+		current = next
+
+		// The pending follow-ups of replaced receivers would be sent with
+		// their previous settings.
+		for name, notifier := range previous {
+			if throttled, ok := notifier.(*notifications.ThrottledNotifier); ok && kept[name] == nil {
+				throttled.Stop()
+			}
+		}
+		log.Printf("Reloaded the configuration with %d receivers.", len(next.Notifications.Receivers))
+	}
+}
+
+// unchangedReceivers lists the receivers configured identically, throttle
+// and notification prefix included, in both configurations.
+func unchangedReceivers(current, next *config.Config) []string {
+	if current.Notifications.NotificationPrefix != next.Notifications.NotificationPrefix {
+		return nil
+	}
+
+	var names []string
+	for _, receiver := range next.Notifications.Receivers {
+		for _, previous := range current.Notifications.Receivers {
+			if previous.Name == receiver.Name && reflect.DeepEqual(previous, receiver) &&
+				receiverThrottle(current, previous) == receiverThrottle(next, receiver) {
+				names = append(names, receiver.Name)
+			}
+		}
+	}
+	return names
+}
+
+func receiverThrottle(cfg *config.Config, receiver config.Receiver) config.Throttle {
+	if receiver.Throttle != nil {
+		return *receiver.Throttle
+	}
+	return cfg.Notifications.Throttle
+}
+
+// restartSettings lists the changed settings a reload cannot apply.
+func restartSettings(current, next *config.Config) []string {
+	var settings []string
+	if current.Namespace != next.Namespace || !slices.Equal(current.Namespaces, next.Namespaces) {
+		settings = append(settings, "namespaces")
+	}
+	if !reflect.DeepEqual(current.Clusters, next.Clusters) {
+		settings = append(settings, "clusters")
+	}
+	if current.CheckInterval != next.CheckInterval {
+		settings = append(settings, "check_interval")
+	}
+	if current.ClusterName != next.ClusterName || current.ClusterDetection != next.ClusterDetection {
+		settings = append(settings, "cluster_name")
+	}
+	if current.Server != next.Server {
+		settings = append(settings, "server")
+	}
+	if current.Reload != next.Reload {
+		settings = append(settings, "reload")
+	}
+	return settings
+}
+
+// resolveSecrets reads the secrets referenced by the configuration from
+// Kubernetes and masks every secret value in the logs.
+func resolveSecrets(cfg *config.Config, vc *controller.VeleroController, redactor *config.Redactor) error {
+	// Secrets read from the environment or files are known already.
+	redactor.Add(cfg.Secrets()...)
+	err := cfg.ResolveSecrets(func(ref config.SecretKeyRef) (string, error) {
+		return vc.SecretValue(ref.Namespace, ref.Name, ref.Key)
+	})
+	redactor.Add(cfg.Secrets()...)
+	return err
+}
+
+// buildRouter creates the receivers, routing tree and maintenance windows of
+// a configuration, reusing the kept receivers. Receivers that fail to
+// initialize are logged and skipped.
+func buildRouter(cfg *config.Config, kept map[string]notifications.Notifier) (*notifications.Router, []notifications.MaintenanceWindow, error) {
+	receivers := make(map[string]notifications.Notifier)
+
+	for _, receiver := range cfg.Notifications.Receivers {
+		if notifier, ok := kept[receiver.Name]; ok {
+			receivers[receiver.Name] = notifier
+			continue
+		}
+		notifier, err := newNotifier(receiver, cfg.Notifications.NotificationPrefix)
+		if err != nil {
+			log.Printf("Failed to initialize receiver %s: %v", receiver.Name, err)
+			continue
+		}
+		throttle := receiverThrottle(cfg, receiver)
+		receivers[receiver.Name] = notifications.NewThrottledNotifier(receiver.Name, notifier, notifications.ThrottleOptions{
+			DedupWindow:   time.Duration(throttle.DedupWindow) * time.Second,
+			RatePerMinute: throttle.RatePerMinute,
+			Burst:         throttle.Burst,
+		})
+	}
+
+	router, err := notifications.NewRouter(toRoute(cfg.Notifications.Route), receivers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid notification routes: %w", err)
+	}
+
+	var windows []notifications.MaintenanceWindow
+	for _, window := range cfg.Notifications.Silences.MaintenanceWindows {
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid schedule for maintenance window %s: %w", window.Name, err)
+		}
+		windows = append(windows, notifications.MaintenanceWindow{
+			Name:     window.Name,
+			Schedule: schedule,
+			Duration: time.Duration(window.Duration) * time.Second,
+			Match:    toRouteMatch(window.Matchers),
+		})
+	}
+
+	return router, windows, nil
+}
+
+func newNotifier(receiver config.Receiver, prefix string) (notifications.Notifier, error) {
+	if slack := receiver.Slack; slack != nil {
+		return notifications.NewSlackNotifier(notifications.SlackConfig{
+			Webhook:      slack.Webhook,
+			Channel:      slack.Channel,
+			Username:     slack.Username,
+			FailuresOnly: slack.FailuresOnly,
+			Prefix:       prefix,
+		})
+	}
+
+	email := receiver.Email
+	return notifications.NewEmailNotifier(notifications.EmailConfig{
+		SMTPServer:   email.SMTPServer,
+		SMTPPort:     email.SMTPPort,
+		Username:     email.Username,
+		Password:     email.Password,
+		From:         email.From,
+		To:           email.To,
+		FailuresOnly: email.FailuresOnly,
+		Prefix:       prefix,
+
+		TLSMode:            email.TLSMode,
+		AuthMechanism:      email.AuthMechanism,
+		CAFile:             email.CAFile,
+		InsecureSkipVerify: email.InsecureSkipVerify,
+		DialTimeout:        time.Duration(email.DialTimeout) * time.Second,
+	})
+}
+
+func toRoute(cfg config.Route) notifications.Route {
+	route := notifications.Route{
+		Receivers: cfg.Receivers,
+		Match:     toRouteMatch(cfg.Matchers),
+		Continue:  cfg.Continue,
+		Mentions:  cfg.Mentions,
+	}
+
+	if cfg.Receiver != "" {
+		route.Receivers = append([]string{cfg.Receiver}, route.Receivers...)
+	}
+
+	for _, child := range cfg.Routes {
+		route.Routes = append(route.Routes, toRoute(child))
+	}
+
+	return route
+}
+
+func toRouteMatch(cfg config.Matchers) notifications.RouteMatch {
+	return notifications.RouteMatch{
+		Schedules:        cfg.Schedules,
+		Labels:           cfg.Labels,
+		Namespaces:       cfg.Namespaces,
+		StorageLocations: cfg.StorageLocations,
+		Phases:           cfg.Phases,
+		VeleroNamespaces: cfg.VeleroNamespaces,
+		Clusters:         cfg.Clusters,
+	}
+}
+
+func toClusters(cfg []config.Cluster) []controller.ClusterOptions {
+	clusters := make([]controller.ClusterOptions, 0, len(cfg))
+	for _, cluster := range cfg {
+		options := controller.ClusterOptions{
+			Name:    cluster.Name,
+			Context: cluster.Context,
+		}
+		if secret := cluster.KubeconfigSecret; secret != nil {
+			options.SecretNamespace = secret.Namespace
+			options.SecretName = secret.Name
+			options.SecretKey = secret.Key
+		}
+		clusters = append(clusters, options)
+	}
+	return clusters
+}
+
+// controllerOptions validates the controller settings of a configuration and
+// returns the function that applies them, so a reload can be rejected
+// before anything changes.
+func controllerOptions(cfg *config.Config, router *notifications.Router, silencer *notifications.Silencer) (func(*controller.VeleroController), error) {
+	stuckSchedules := make(map[string]time.Duration, len(cfg.Monitoring.StuckBackups.Schedules))
+	for schedule, seconds := range cfg.Monitoring.StuckBackups.Schedules {
+		stuckSchedules[schedule] = time.Duration(seconds) * time.Second
+	}
+
+	retentionSelector, err := labels.Parse(cfg.Monitoring.Deletions.RetentionSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deletions retention_selector: %w", err)
+	}
+	if cfg.Monitoring.Deletions.RetentionSelector == "" {
+		// An empty selector matches every backup; the heads-up is meant
+		// for the long-term retention ones only.
+		retentionSelector = nil
+	}
+
+	if _, err := labels.Parse(cfg.Filters.LabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid filters label_selector: %w", err)
+	}
+	backupFilter, err := controller.NewNameFilter(cfg.Filters.Backups.Include, cfg.Filters.Backups.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup filters: %w", err)
+	}
+	scheduleFilter, err := controller.NewNameFilter(cfg.Filters.Schedules.Include, cfg.Filters.Schedules.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule filters: %w", err)
+	}
+
+	var digests []controller.DigestOptions
+	for _, digest := range cfg.Notifications.Digests {
+		schedule, err := cron.ParseStandard(digest.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule for digest %s: %w", digest.Name, err)
+		}
+		for _, receiver := range digest.Receivers {
+			if !router.HasReceiver(receiver) {
+				return nil, fmt.Errorf("digest %s references unknown receiver %q", digest.Name, receiver)
+			}
+		}
+		digests = append(digests, controller.DigestOptions{
+			Name:      digest.Name,
+			Schedule:  schedule,
+			Receivers: digest.Receivers,
+		})
+	}
+
+	return func(vc *controller.VeleroController) {
+		vc.Verbose = cfg.Logging.Verbose
+
+		vc.BackupLogs = controller.BackupLogsOptions{
+			Enabled:           cfg.Notifications.BackupLogs.Enabled,
+			MaxAttachmentSize: cfg.Notifications.BackupLogs.MaxAttachmentSize,
+			MaxErrorLines:     cfg.Notifications.BackupLogs.MaxErrorLines,
+			Timeout:           time.Duration(cfg.Notifications.BackupLogs.Timeout) * time.Second,
+		}
+
+		vc.BackupResults = controller.BackupResultsOptions{
+			Enabled: cfg.Notifications.BackupResults.Enabled,
+			Timeout: time.Duration(cfg.Notifications.BackupResults.Timeout) * time.Second,
+		}
+
+		vc.MissedSchedules = controller.MissedSchedulesOptions{
+			Enabled:     cfg.Monitoring.MissedSchedules.Enabled,
+			GracePeriod: time.Duration(cfg.Monitoring.MissedSchedules.GracePeriod) * time.Second,
+		}
+
+		vc.StuckBackups = controller.StuckBackupsOptions{
+			Enabled:     cfg.Monitoring.StuckBackups.Enabled,
+			MaxDuration: time.Duration(cfg.Monitoring.StuckBackups.MaxDuration) * time.Second,
+			Schedules:   stuckSchedules,
+		}
+
+		vc.Regressions = controller.RegressionsOptions{
+			Enabled:       cfg.Monitoring.Regressions.Enabled,
+			Window:        cfg.Monitoring.Regressions.Window,
+			MinSamples:    cfg.Monitoring.Regressions.MinSamples,
+			DurationRatio: cfg.Monitoring.Regressions.DurationRatio,
+			ItemsRatio:    cfg.Monitoring.Regressions.ItemsRatio,
+		}
+
+		vc.StorageLocations = controller.StorageLocationsOptions{
+			Enabled: cfg.Monitoring.StorageLocations.Enabled,
+		}
+
+		vc.VolumeBackups = controller.VolumeBackupsOptions{
+			Enabled: cfg.Notifications.VolumeBackups.Enabled,
+		}
+
+		vc.Deletions = controller.DeletionsOptions{
+			Enabled:           cfg.Monitoring.Deletions.Enabled,
+			ExpiryWarning:     time.Duration(cfg.Monitoring.Deletions.ExpiryWarningDays) * 24 * time.Hour,
+			RetentionSelector: retentionSelector,
+		}
+
+		vc.Filters = controller.FiltersOptions{
+			LabelSelector: cfg.Filters.LabelSelector,
+			Backups:       backupFilter,
+			Schedules:     scheduleFilter,
+			OnlyScheduled: cfg.Filters.OnlyScheduled,
+		}
+
+		vc.Health = controller.HealthOptions{
+			FailureThreshold: cfg.Monitoring.APIHealth.FailureThreshold,
+		}
+
+		vc.Recoveries = controller.RecoveriesOptions{
+			Enabled:     cfg.Monitoring.Recoveries.Enabled,
+			MinFailures: cfg.Monitoring.Recoveries.MinFailures,
+		}
+
+		vc.Snapshots = controller.SnapshotsOptions{
+			Enabled:       cfg.Monitoring.Snapshots.Enabled,
+			VolumeDetails: cfg.Monitoring.Snapshots.VolumeDetails,
+			Timeout:       time.Duration(cfg.Monitoring.Snapshots.Timeout) * time.Second,
+		}
+
+		vc.Digests = digests
+
+		vc.Silences = controller.SilencesOptions{
+			Silencer:     silencer,
+			ConfigMap:    cfg.Notifications.Silences.ConfigMap,
+			SyncInterval: time.Duration(cfg.Notifications.Silences.SyncInterval) * time.Second,
+		}
+	}, nil
+}
@@ -0,0 +1,124 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Digest summarizes the backups that finished during a period, one row per
+// schedule.
+type Digest struct {
+	Name      string
+	Since     time.Time
+	Until     time.Time
+	Schedules []DigestSchedule
+}
+
+// DigestSchedule aggregates the backups of one schedule. Ad hoc backups are
+// grouped under an empty Schedule.
+type DigestSchedule struct {
+	Schedule        string
+	Completed       int
+	PartiallyFailed int
+	Failed          int
+	TotalDuration   time.Duration
+	Slowest         string
+	SlowestDuration time.Duration
+	LastFailure     string
+}
+
+const adHocDigestSchedule = "(ad hoc)"
+
+func (s DigestSchedule) name() string {
+	if s.Schedule == "" {
+		return adHocDigestSchedule
+	}
+	return s.Schedule
+}
+
+func (s DigestSchedule) slowest() string {
+	if s.Slowest == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", s.Slowest, FormatDuration(s.SlowestDuration))
+}
+
+func (s DigestSchedule) lastFailure() string {
+	if s.LastFailure == "" {
+		return "-"
+	}
+	return s.LastFailure
+}
+
+// digestTable renders the digest as a plain text table, used in emails and
+// in a Slack code block.
+func digestTable(digest *Digest, lastFailureLength int) string {
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Schedule\tOK\tPartial\tFailed\tTotal Time\tSlowest\tLast Failure")
+	for _, schedule := range digest.Schedules {
+		lastFailure := schedule.lastFailure()
+		if lastFailureLength > 0 {
+			lastFailure = truncateText(lastFailure, lastFailureLength)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			schedule.name(), schedule.Completed, schedule.PartiallyFailed, schedule.Failed,
+			FormatDuration(schedule.TotalDuration), schedule.slowest(), lastFailure)
+	}
+	_ = writer.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+var digestHTMLTemplate = template.Must(template.New("digest").Parse(`<html>
+<body style="font-family: sans-serif;">
+<h2>{{ .Title }}</h2>
+<p>{{ .Since }} &ndash; {{ .Until }}</p>
+<table cellpadding="6" cellspacing="0" border="1" style="border-collapse: collapse;">
+<tr><th>Schedule</th><th>Completed</th><th>Partially Failed</th><th>Failed</th><th>Total Time</th><th>Slowest</th><th>Last Failure</th></tr>
+{{- range .Rows }}
+<tr><td>{{ .Name }}</td><td>{{ .Completed }}</td><td>{{ .PartiallyFailed }}</td><td{{ if .Failed }} style="color: #8B0000; font-weight: bold;"{{ end }}>{{ .Failed }}</td><td>{{ .TotalDuration }}</td><td>{{ .Slowest }}</td><td>{{ .LastFailure }}</td></tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// digestHTML renders the digest as an HTML table for emails.
+func digestHTML(title string, digest *Digest) (string, error) {
+	type row struct {
+		Name                               string
+		Completed, PartiallyFailed, Failed int
+		TotalDuration                      string
+		Slowest, LastFailure               string
+	}
+
+	data := struct {
+		Title, Since, Until string
+		Rows                []row
+	}{
+		Title: title,
+		Since: digest.Since.Format(time.RFC1123),
+		Until: digest.Until.Format(time.RFC1123),
+	}
+	for _, schedule := range digest.Schedules {
+		data.Rows = append(data.Rows, row{
+			Name:            schedule.name(),
+			Completed:       schedule.Completed,
+			PartiallyFailed: schedule.PartiallyFailed,
+			Failed:          schedule.Failed,
+			TotalDuration:   FormatDuration(schedule.TotalDuration),
+			Slowest:         schedule.slowest(),
+			LastFailure:     schedule.lastFailure(),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := digestHTMLTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDigest() *Digest {
+	since := time.Date(2026, 3, 17, 8, 0, 0, 0, time.UTC)
+	return &Digest{
+		Name:  "daily",
+		Since: since,
+		Until: since.Add(24 * time.Hour),
+		Schedules: []DigestSchedule{
+			{Schedule: "nightly", Completed: 1, Failed: 1, TotalDuration: 95 * time.Minute, Slowest: "nightly-20260318", SlowestDuration: time.Hour, LastFailure: "nightly-20260317: <timeout>"},
+			{Completed: 2, TotalDuration: 90 * time.Second, Slowest: "manual-1", SlowestDuration: time.Minute},
+		},
+	}
+}
+
+func TestDigestTableAlignsColumns(t *testing.T) {
+	t.Parallel()
+
+	lines := strings.Split(digestTable(testDigest(), 0), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two rows, got %q", lines)
+	}
+
+	if !strings.HasPrefix(lines[2], "(ad hoc)") || !strings.Contains(lines[1], "nightly-20260318 (1h)") {
+		t.Fatalf("unexpected rows %q", lines)
+	}
+
+	column := strings.Index(lines[0], "Slowest")
+	if strings.Index(lines[1], "nightly-20260318") != column || strings.Index(lines[2], "manual-1") != column {
+		t.Fatalf("expected aligned columns:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestBuildEmailMessageWithDigestHTML(t *testing.T) {
+	t.Parallel()
+
+	rendered, err := digestHTML("[Velero] Backup Digest", testDigest())
+	if err != nil {
+		t.Fatalf("render digest: %v", err)
+	}
+
+	if !strings.Contains(rendered, "nightly-20260317: &lt;timeout&gt;") {
+		t.Fatalf("expected escaped failure reason in %q", rendered)
+	}
+
+	msg, err := buildEmailMessage("ops@example.com", "[Velero] Backup Digest", "Backup digest daily.", rendered, nil)
+	if err != nil {
+		t.Fatalf("build message: %v", err)
+	}
+
+	text := string(msg)
+	for _, want := range []string{"Content-Type: multipart/alternative; boundary=", "Content-Type: text/plain; charset=utf-8", "Content-Type: text/html; charset=utf-8", "<table"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in message:\n%s", want, text)
+		}
+	}
+}
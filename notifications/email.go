@@ -1,13 +1,48 @@
 package notifications
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	TLSModeNone = "none"
+	// TLSModeOpportunistic upgrades with STARTTLS when the server offers
+	// it, as smtp.SendMail does.
+	TLSModeOpportunistic = "opportunistic"
+	TLSModeStartTLS      = "starttls"
+	TLSModeImplicit      = "implicit"
+
+	AuthPlain   = "plain"
+	AuthLogin   = "login"
+	AuthCRAMMD5 = "cram-md5"
+	AuthXOAuth2 = "xoauth2"
+
+	defaultSMTPDialTimeout = 10 * time.Second
+	smtpSessionTimeout     = 30 * time.Second
+)
+
+var emailResultLimits = resultLimits{namespaces: 50, messagesPerScope: 20, messageLength: 1000}
+
+const emailVolumeLimit = 200
+
 type EmailNotifier struct {
-	config EmailConfig
+	config    EmailConfig
+	tlsConfig *tls.Config
 }
 
 type EmailConfig struct {
@@ -19,37 +54,422 @@ type EmailConfig struct {
 	To           string
 	FailuresOnly bool
 	Prefix       string
+	// TLSMode is one of none, opportunistic, starttls or implicit. When
+	// empty, implicit is used for port 465 and opportunistic for everything
+	// else.
+	TLSMode string
+	// AuthMechanism is one of plain, login, cram-md5 or xoauth2. For xoauth2
+	// the Password carries the OAuth2 access token.
+	AuthMechanism      string
+	CAFile             string
+	InsecureSkipVerify bool
+	DialTimeout        time.Duration
 }
 
 func NewEmailNotifier(cfg EmailConfig) (*EmailNotifier, error) {
 	if cfg.SMTPServer == "" {
 		return nil, fmt.Errorf("error trying to configure email")
 	}
-	return &EmailNotifier{config: cfg}, nil
+
+	cfg.TLSMode = strings.ToLower(strings.TrimSpace(cfg.TLSMode))
+	if cfg.TLSMode == "" {
+		cfg.TLSMode = TLSModeOpportunistic
+		if cfg.SMTPPort == 465 {
+			cfg.TLSMode = TLSModeImplicit
+		}
+	}
+
+	switch cfg.TLSMode {
+	case TLSModeNone, TLSModeOpportunistic, TLSModeStartTLS, TLSModeImplicit:
+	default:
+		return nil, fmt.Errorf("invalid tls_mode %q: must be one of none, opportunistic, starttls or implicit", cfg.TLSMode)
+	}
+
+	cfg.AuthMechanism = strings.ToLower(strings.TrimSpace(cfg.AuthMechanism))
+	if cfg.AuthMechanism == "" {
+		cfg.AuthMechanism = AuthPlain
+	}
+
+	switch cfg.AuthMechanism {
+	case AuthPlain, AuthLogin, AuthCRAMMD5, AuthXOAuth2:
+	default:
+		return nil, fmt.Errorf("invalid auth_mechanism %q: must be one of plain, login, cram-md5 or xoauth2", cfg.AuthMechanism)
+	}
+
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaultSMTPDialTimeout
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.SMTPServer,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_file %q does not contain any PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &EmailNotifier{config: cfg, tlsConfig: tlsConfig}, nil
 }
 
 func (e *EmailNotifier) Notify(status, message string) error {
+	return e.NotifyEvent(Event{Status: status, Message: message})
+}
+
+func (e *EmailNotifier) NotifyEvent(event Event) error {
+	status, message := event.Status, event.Message
 	log.Printf("[Email] Sending notification for %s: %s", status, message)
-	// If FailuresOnly is enabled, only proceed for failure states
-	if e.config.FailuresOnly {
-		switch status {
-		case "Failed", "PartiallyFailed", "FinalizingPartiallyFailed", "Unknown":
+	// If FailuresOnly is enabled, only proceed for failure and recovery states
+	if (e.config.FailuresOnly || event.Overrides.FailuresOnly) && failuresOnlyDrops(event, status) {
+		return nil
+	}
+
+	body := message
+	if event.Warnings > 0 || event.Errors > 0 {
+		body = strings.TrimRight(body, "\n") + fmt.Sprintf("\nWarnings: %d, Errors: %d", event.Warnings, event.Errors)
+	}
+	if event.Cluster != "" {
+		body = strings.TrimRight(body, "\n") + "\nCluster: " + event.Cluster
+	}
+	if event.VeleroNamespace != "" {
+		body = strings.TrimRight(body, "\n") + "\nVelero Namespace: " + event.VeleroNamespace
+	}
+	if mentions := emailMentions(event.Mentions); mentions != "" {
+		body = strings.TrimRight(body, "\n") + "\nMentions: " + mentions
+	}
+
+	if event.Results != nil {
+		if lines := summarizeResults(event.Results.Errors, emailResultLimits); len(lines) > 0 {
+			body += "\n\nErrors by namespace:\n  " + strings.Join(lines, "\n  ")
+		}
+		if lines := summarizeResults(event.Results.Warnings, emailResultLimits); len(lines) > 0 {
+			body += "\n\nWarnings by namespace:\n  " + strings.Join(lines, "\n  ")
+		}
+	}
 
-		default:
-			return nil
+	if lines := summarizeVolumes(event.Volumes, emailVolumeLimit); len(lines) > 0 {
+		body += "\n\nVolumes:\n  " + strings.Join(lines, "\n  ")
+	}
+
+	if len(event.LogExcerpt) > 0 {
+		body += "\n\nLog Errors:\n" + strings.Join(event.LogExcerpt, "\n")
+	}
+
+	subject := e.config.Prefix + " Backup " + status
+	if event.Cluster != "" {
+		subject = e.config.Prefix + " [" + event.Cluster + "] Backup " + status
+	}
+	htmlBody := ""
+	if event.Digest != nil {
+		body += "\n\n" + digestTable(event.Digest, 0)
+		rendered, err := digestHTML(strings.TrimSpace(subject), event.Digest)
+		if err != nil {
+			return fmt.Errorf("render digest: %w", err)
 		}
+		htmlBody = rendered
 	}
 
-	var auth smtp.Auth
-	if e.config.Username != "" && e.config.Password != "" {
-		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.SMTPServer)
+	msg, err := buildEmailMessage(e.config.To, subject, body, htmlBody, event.Attachments)
+	if err != nil {
+		return fmt.Errorf("build email message: %w", err)
 	}
 
-	msg := []byte("To: " + e.config.To + "\r\n" +
-		"Subject: " + e.config.Prefix + " Backup " + status + "\r\n" +
-		"\r\n" +
-		message +
+	return e.send([]string{e.config.To}, msg)
+}
+
+// buildEmailMessage renders a plain text message, switching to a
+// multipart/mixed body when there are attachments and adding an HTML
+// alternative when htmlBody is set.
+func buildEmailMessage(to, subject, body, htmlBody string, attachments []Attachment) ([]byte, error) {
+	if len(attachments) == 0 && htmlBody == "" {
+		return []byte("To: " + to + "\r\n" +
+			"Subject: " + subject + "\r\n" +
+			"\r\n" +
+			body +
+			"\r\n"), nil
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	contentType := "multipart/mixed"
+	if len(attachments) == 0 {
+		contentType = "multipart/alternative"
+	}
+
+	buf.WriteString("To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: " + contentType + "; boundary=" + writer.Boundary() + "\r\n" +
 		"\r\n")
-	addr := fmt.Sprintf("%s:%d", e.config.SMTPServer, e.config.SMTPPort)
-	return smtp.SendMail(addr, auth, e.config.From, []string{e.config.To}, msg)
+
+	switch {
+	case htmlBody == "":
+		if err := writeTextPart(writer, "text/plain; charset=utf-8", body); err != nil {
+			return nil, err
+		}
+	case len(attachments) == 0:
+		if err := writeAlternativeParts(writer, body, htmlBody); err != nil {
+			return nil, err
+		}
+	default:
+		var alternative bytes.Buffer
+		inner := multipart.NewWriter(&alternative)
+		if err := writeAlternativeParts(inner, body, htmlBody); err != nil {
+			return nil, err
+		}
+		if err := inner.Close(); err != nil {
+			return nil, err
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"multipart/alternative; boundary=" + inner.Boundary()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(alternative.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, attachment := range attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeAlternativeParts(writer *multipart.Writer, body, htmlBody string) error {
+	if err := writeTextPart(writer, "text/plain; charset=utf-8", body); err != nil {
+		return err
+	}
+	return writeTextPart(writer, "text/html; charset=utf-8", htmlBody)
+}
+
+func writeTextPart(writer *multipart.Writer, contentType, text string) error {
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {contentType},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n") + "\r\n"))
+	return err
+}
+
+func (e *EmailNotifier) send(to []string, msg []byte) error {
+	addr := net.JoinHostPort(e.config.SMTPServer, strconv.Itoa(e.config.SMTPPort))
+	dialer := &net.Dialer{Timeout: e.config.DialTimeout}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if e.config.TLSMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, e.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("dial smtp server %s: %w", addr, err)
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(smtpSessionTimeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("set smtp deadline: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, e.config.SMTPServer)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if e.config.TLSMode == TLSModeStartTLS || e.config.TLSMode == TLSModeOpportunistic {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok && e.config.TLSMode == TLSModeStartTLS {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if ok {
+			if err := client.StartTLS(e.tlsConfig); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		}
+	}
+
+	if auth := e.auth(); auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth (%s): %w", e.config.AuthMechanism, err)
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("write smtp message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("close smtp message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (e *EmailNotifier) auth() smtp.Auth {
+	if e.config.Username == "" || e.config.Password == "" {
+		return nil
+	}
+
+	switch e.config.AuthMechanism {
+	case AuthLogin:
+		return &loginAuth{username: e.config.Username, password: e.config.Password, host: e.config.SMTPServer}
+	case AuthCRAMMD5:
+		return smtp.CRAMMD5Auth(e.config.Username, e.config.Password)
+	case AuthXOAuth2:
+		return &xoauth2Auth{username: e.config.Username, token: e.config.Password, host: e.config.SMTPServer}
+	default:
+		return smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.SMTPServer)
+	}
+}
+
+// loginAuth implements the non-standard but widely deployed AUTH LOGIN
+// mechanism. Like smtp.PlainAuth it refuses to send credentials over an
+// unencrypted connection unless the server is on localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected AUTH LOGIN challenge %q", fromServer)
+	}
+}
+
+// xoauth2Auth implements the XOAUTH2 mechanism used by Office 365 and Gmail.
+type xoauth2Auth struct {
+	username string
+	token    string
+	host     string
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.token + "\x01\x01"), nil
+}
+
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		// The server sends a base64 JSON error document and expects an
+		// empty response before it replies with the final failure code.
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// emailMentions renders mentions as plain text, turning the chat syntax
+// into names: "<@U123>" becomes "@U123", "<!here>" becomes "@here" and
+// "<!subteam^S123|@dba>" becomes "@dba".
+func emailMentions(mentions []string) string {
+	var rendered []string
+	for _, mention := range mentions {
+		mention = strings.TrimSpace(mention)
+		if strings.HasPrefix(mention, "<") && strings.HasSuffix(mention, ">") {
+			mention = strings.TrimSuffix(strings.TrimPrefix(mention, "<"), ">")
+			if _, label, ok := strings.Cut(mention, "|"); ok {
+				mention = label
+			}
+			mention = strings.TrimPrefix(strings.TrimPrefix(mention, "!"), "subteam^")
+		}
+		mention = strings.TrimPrefix(mention, "@")
+		if mention != "" {
+			rendered = append(rendered, "@"+mention)
+		}
+	}
+	return strings.Join(rendered, ", ")
 }
@@ -0,0 +1,294 @@
+package notifications
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTPServer is a minimal plaintext SMTP server that records the
+// conversation so tests can assert on the commands a notifier issued.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	srv := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go srv.serve()
+	t.Cleanup(func() { listener.Close() })
+	return srv
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	read := func() string {
+		line, _ := r.ReadString('\n')
+		return strings.TrimRight(line, "\r\n")
+	}
+
+	write("220 fake ESMTP")
+	for {
+		line := read()
+		if line == "" {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		verb := strings.ToUpper(strings.Fields(line)[0])
+		switch verb {
+		case "EHLO":
+			write("250-fake")
+			write("250 AUTH PLAIN LOGIN")
+		case "AUTH":
+			if strings.HasPrefix(strings.ToUpper(line), "AUTH LOGIN") {
+				write("334 " + base64.StdEncoding.EncodeToString([]byte("Username:")))
+				s.record(decodeBase64(read()))
+				write("334 " + base64.StdEncoding.EncodeToString([]byte("Password:")))
+				s.record(decodeBase64(read()))
+			}
+			write("235 ok")
+		case "DATA":
+			write("354 go ahead")
+			var body strings.Builder
+			for {
+				l := read()
+				if l == "." {
+					break
+				}
+				body.WriteString(l + "\n")
+			}
+			s.mu.Lock()
+			s.data = body.String()
+			s.mu.Unlock()
+			write("250 queued")
+		case "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) record(line string) {
+	s.mu.Lock()
+	s.commands = append(s.commands, line)
+	s.mu.Unlock()
+}
+
+func decodeBase64(value string) string {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return value
+	}
+	return string(decoded)
+}
+
+func TestNewEmailNotifierDefaultsTLSModeFromPort(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]string{
+		465: TLSModeImplicit,
+		587: TLSModeOpportunistic,
+		25:  TLSModeOpportunistic,
+	}
+
+	for port, want := range cases {
+		notifier, err := NewEmailNotifier(EmailConfig{SMTPServer: "smtp.example.com", SMTPPort: port})
+		if err != nil {
+			t.Fatalf("new notifier for port %d: %v", port, err)
+		}
+		if notifier.config.TLSMode != want {
+			t.Fatalf("port %d: expected tls mode %q, got %q", port, want, notifier.config.TLSMode)
+		}
+	}
+}
+
+func TestNewEmailNotifierRejectsInvalidSettings(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewEmailNotifier(EmailConfig{SMTPServer: "smtp.example.com", TLSMode: "ssl"}); err == nil {
+		t.Fatal("expected error for unknown tls_mode")
+	}
+
+	if _, err := NewEmailNotifier(EmailConfig{SMTPServer: "smtp.example.com", AuthMechanism: "ntlm"}); err == nil {
+		t.Fatal("expected error for unknown auth_mechanism")
+	}
+
+	if _, err := NewEmailNotifier(EmailConfig{SMTPServer: "smtp.example.com", CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Fatal("expected error for missing ca_file")
+	}
+}
+
+func TestEmailNotifierNotifySendsWithLoginAuth(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	notifier, err := NewEmailNotifier(EmailConfig{
+		SMTPServer:    "127.0.0.1",
+		SMTPPort:      srv.port(),
+		Username:      "velero",
+		Password:      "s3cret",
+		From:          "velero@example.com",
+		To:            "ops@example.com",
+		Prefix:        "[Velero]",
+		TLSMode:       TLSModeNone,
+		AuthMechanism: AuthLogin,
+	})
+	if err != nil {
+		t.Fatalf("new notifier: %v", err)
+	}
+
+	if err := notifier.Notify("Failed", "Backup demo finished with status: Failed."); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	<-srv.done
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	joined := strings.Join(srv.commands, "\n")
+	for _, want := range []string{"AUTH LOGIN", "velero", "s3cret", "MAIL FROM:<velero@example.com>", "RCPT TO:<ops@example.com>"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected SMTP conversation to contain %q, got:\n%s", want, joined)
+		}
+	}
+
+	if !strings.Contains(srv.data, "Subject: [Velero] Backup Failed") {
+		t.Fatalf("expected subject header in message, got:\n%s", srv.data)
+	}
+}
+
+func TestEmailNotifierStartTLSRequiresServerSupport(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	notifier, err := NewEmailNotifier(EmailConfig{
+		SMTPServer: "127.0.0.1",
+		SMTPPort:   srv.port(),
+		From:       "velero@example.com",
+		To:         "ops@example.com",
+		TLSMode:    TLSModeStartTLS,
+	})
+	if err != nil {
+		t.Fatalf("new notifier: %v", err)
+	}
+
+	err = notifier.Notify("Failed", "Backup demo failed")
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestEmailNotifierDefaultSendsWithoutStartTLSSupport(t *testing.T) {
+	t.Parallel()
+
+	srv := newFakeSMTPServer(t)
+
+	notifier, err := NewEmailNotifier(EmailConfig{
+		SMTPServer: "127.0.0.1",
+		SMTPPort:   srv.port(),
+		From:       "velero@example.com",
+		To:         "ops@example.com",
+	})
+	if err != nil {
+		t.Fatalf("new notifier: %v", err)
+	}
+
+	if err := notifier.Notify("Failed", "Backup demo failed"); err != nil {
+		t.Fatalf("expected a relay without STARTTLS to keep working, got %v", err)
+	}
+	<-srv.done
+}
+
+func TestXOAuth2AuthInitialResponse(t *testing.T) {
+	t.Parallel()
+
+	auth := &xoauth2Auth{username: "user@example.com", token: "ya29.token", host: "smtp.office365.com"}
+
+	mech, resp, err := auth.Start(&smtp.ServerInfo{Name: "smtp.office365.com", TLS: true})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if mech != "XOAUTH2" {
+		t.Fatalf("expected XOAUTH2 mechanism, got %q", mech)
+	}
+
+	want := "user=user@example.com\x01auth=Bearer ya29.token\x01\x01"
+	if string(resp) != want {
+		t.Fatalf("expected initial response %s, got %s", strconv.Quote(want), strconv.Quote(string(resp)))
+	}
+
+	if _, _, err := auth.Start(&smtp.ServerInfo{Name: "smtp.office365.com"}); err == nil {
+		t.Fatal("expected XOAUTH2 to refuse unencrypted connections")
+	}
+}
+
+func TestBuildEmailMessageWithAttachment(t *testing.T) {
+	t.Parallel()
+
+	msg, err := buildEmailMessage("ops@example.com", "[Velero] Backup Failed", "Backup demo failed.", "", []Attachment{{
+		Filename:    "demo-logs.gz",
+		ContentType: "application/gzip",
+		Data:        []byte{0x1f, 0x8b, 0x08},
+	}})
+	if err != nil {
+		t.Fatalf("build message: %v", err)
+	}
+
+	content := string(msg)
+	for _, want := range []string{
+		"Content-Type: multipart/mixed; boundary=",
+		"Backup demo failed.",
+		`Content-Disposition: attachment; filename=demo-logs.gz`,
+		"Content-Type: application/gzip",
+		base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x08}),
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected message to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestEmailMentionsRendersPlainText(t *testing.T) {
+	t.Parallel()
+
+	got := emailMentions([]string{"<!here>", "U123", "<@U456>", "<!subteam^S123|@dba>", "<!subteam^S789>", " "})
+	if want := "@here, @U123, @U456, @dba, @S789"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
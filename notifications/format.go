@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatDuration renders a duration rounded to the minute, e.g. "5h12m".
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+
+	if d >= 24*time.Hour {
+		// Minutes are noise at this scale; show days and hours.
+		d = d.Round(time.Hour)
+		formatted := fmt.Sprintf("%dd", d/(24*time.Hour))
+		if hours := (d % (24 * time.Hour)) / time.Hour; hours > 0 {
+			formatted += fmt.Sprintf("%dh", hours)
+		}
+		return formatted
+	}
+
+	formatted := strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
+
+// FormatBytes renders a size with binary units, e.g. "1.5 GiB".
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,22 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	t.Parallel()
+
+	for d, want := range map[time.Duration]string{
+		42 * time.Second:           "42s",
+		72 * time.Minute:           "1h12m",
+		5 * time.Hour:              "5h",
+		3 * 24 * time.Hour:         "3d",
+		50*time.Hour + time.Minute: "2d2h",
+	} {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
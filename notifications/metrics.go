@@ -0,0 +1,31 @@
+package notifications
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons an event was not delivered to a receiver.
+const (
+	SuppressedBySilence   = "silence"
+	SuppressedByMute      = "mute"
+	SuppressedByDedup     = "dedup"
+	SuppressedByRateLimit = "rate_limit"
+)
+
+var (
+	dispatchedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "velero_notifications_dispatched_total",
+		Help: "Events handed to a receiver, by cluster, receiver and status.",
+	}, []string{"cluster", "receiver", "status"})
+
+	failedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "velero_notifications_failed_total",
+		Help: "Events a receiver failed to deliver, by cluster and receiver.",
+	}, []string{"cluster", "receiver"})
+
+	suppressedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "velero_notifications_suppressed_total",
+		Help: "Events not delivered to a receiver, by cluster, receiver and reason: silence, mute, dedup or rate_limit.",
+	}, []string{"cluster", "receiver", "reason"})
+)
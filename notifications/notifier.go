@@ -1,5 +1,113 @@
 package notifications
 
+import "strings"
+
 type Notifier interface {
 	Notify(status, message string) error
+	NotifyEvent(event Event) error
+}
+
+// Event is a notification together with the optional details gathered by
+// the controller. Notifiers render whatever parts they support.
+type Event struct {
+	Status  string
+	Message string
+	// Backup metadata used by the router.
+	Cluster         string
+	VeleroNamespace string
+	BackupName      string
+	Schedule        string
+	Labels          map[string]string
+	// Namespaces are the namespaces the event covers; "*" stands for every
+	// namespace. Events without namespaces only match routes that do not
+	// filter on them.
+	Namespaces      []string
+	StorageLocation string
+	// Phase is the Velero phase of the backup or restore, which Status may
+	// refine, such as Recovered for a Completed backup.
+	Phase string
+	// Warnings and Errors are the counts reported in the backup status.
+	Warnings int
+	Errors   int
+	// LogExcerpt holds the first error lines of the backup log.
+	LogExcerpt  []string
+	Attachments []Attachment
+	// Results holds the per-namespace warnings and errors of the backup.
+	Results *BackupResults
+	// Volumes holds the per-volume outcome of the backup, when known.
+	Volumes []VolumeStatus
+	// Digest is set on periodic summary events.
+	Digest *Digest
+	// Overrides are set by the annotations of the backup or schedule.
+	Overrides Overrides
+	// Mentions are set by the router from the matched routes and the
+	// overrides.
+	Mentions []string
+	// FollowUp marks an event that closes an earlier warning, such as the
+	// completion of a backup reported as stuck. failures_only receivers get
+	// it whatever its status.
+	FollowUp bool
+}
+
+// failureStatuses are the statuses a failures_only receiver is sent: the
+// failures, the recoveries and the reports, keyed by normalized status.
+var failureStatuses = map[string]bool{
+	"failed":                    true,
+	"partiallyfailed":           true,
+	"finalizingpartiallyfailed": true,
+	"unknown":                   true,
+	"finalizing":                true,
+	"missed":                    true,
+	"failedvalidation":          true,
+	"paused":                    true,
+	"stuck":                     true,
+	"anomaly":                   true,
+	"unavailable":               true,
+	"available":                 true,
+	"incompletesnapshots":       true,
+	"deletionfailed":            true,
+	"recovered":                 true,
+	"digest":                    true,
+	"contactlost":               true,
+	"contactrestored":           true,
+	"reloadfailed":              true,
+}
+
+// failuresOnlyDrops reports whether a failures_only receiver drops event,
+// whose status the notifier may have inferred from the message.
+func failuresOnlyDrops(event Event, status string) bool {
+	return !event.FollowUp && !IsFailureStatus(status)
+}
+
+// IsFailureStatus reports whether events with status pass the failures_only
+// filter.
+func IsFailureStatus(status string) bool {
+	return failureStatuses[normalizeStatus(status)]
+}
+
+func normalizeStatus(status string) string {
+	status = strings.TrimSpace(strings.ToLower(status))
+	status = strings.ReplaceAll(status, " ", "")
+	status = strings.ReplaceAll(status, "_", "")
+	status = strings.ReplaceAll(status, "-", "")
+	return status
+}
+
+// Overrides let the owners of a backup or schedule adjust its notifications
+// without editing the central configuration.
+type Overrides struct {
+	// Receivers are added to the receivers selected by the routes.
+	Receivers []string
+	// FailuresOnly drops the events a failures_only receiver would drop.
+	FailuresOnly bool
+	// Mute drops every event.
+	Mute bool
+	// Mentions are added to the mentions of the matched routes.
+	Mentions []string
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
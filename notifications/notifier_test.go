@@ -0,0 +1,26 @@
+package notifications
+
+import "testing"
+
+func TestIsFailureStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"Failed":              true,
+		"PartiallyFailed":     true,
+		"partially-failed":    true,
+		"IncompleteSnapshots": true,
+		"Recovered":           true,
+		"ReloadFailed":        true,
+		"Unavailable":         true,
+		"Available":           true,
+		"Completed":           false,
+		"InProgress":          false,
+	}
+
+	for status, want := range cases {
+		if got := IsFailureStatus(status); got != want {
+			t.Fatalf("%s: expected %v, got %v", status, want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scopes used by BackupResults for messages that are not tied to a
+// namespace. Parentheses keep them apart from real namespace names.
+const (
+	ResultScopeVelero  = "(velero)"
+	ResultScopeCluster = "(cluster-scoped)"
+)
+
+// BackupResults holds the warning and error messages of a backup grouped by
+// namespace, as found in the BackupResults file Velero uploads.
+type BackupResults struct {
+	Errors   map[string][]string
+	Warnings map[string][]string
+}
+
+// resultLimits bounds how much of the results a channel renders.
+type resultLimits struct {
+	namespaces       int
+	messagesPerScope int
+	messageLength    int
+}
+
+// summarizeResults renders one line per scope, Velero and cluster scopes
+// first, then namespaces alphabetically, truncated to the given limits.
+func summarizeResults(results map[string][]string, limits resultLimits) []string {
+	if len(results) == 0 {
+		return nil
+	}
+
+	scopes := make([]string, 0, len(results))
+	for scope := range results {
+		if scope != ResultScopeVelero && scope != ResultScopeCluster {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+
+	for _, scope := range []string{ResultScopeCluster, ResultScopeVelero} {
+		if _, ok := results[scope]; ok {
+			scopes = append([]string{scope}, scopes...)
+		}
+	}
+
+	var lines []string
+	for i, scope := range scopes {
+		if limits.namespaces > 0 && i >= limits.namespaces {
+			lines = append(lines, fmt.Sprintf("… and %d more namespaces", len(scopes)-i))
+			break
+		}
+
+		messages := results[scope]
+		shown := messages
+		if limits.messagesPerScope > 0 && len(shown) > limits.messagesPerScope {
+			shown = shown[:limits.messagesPerScope]
+		}
+
+		rendered := make([]string, 0, len(shown))
+		for _, message := range shown {
+			message = strings.TrimSpace(message)
+			if limits.messageLength > 0 {
+				message = truncateText(message, limits.messageLength)
+			}
+			rendered = append(rendered, message)
+		}
+
+		line := fmt.Sprintf("%s (%d): %s", scope, len(messages), strings.Join(rendered, "; "))
+		if hidden := len(messages) - len(shown); hidden > 0 {
+			line += fmt.Sprintf(" (+%d more)", hidden)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,38 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeResultsOrdersAndTruncates(t *testing.T) {
+	t.Parallel()
+
+	results := map[string][]string{
+		"zeta":             {"z1"},
+		"alpha":            {"a1", "a2", "a3"},
+		ResultScopeVelero:  {"velero error"},
+		ResultScopeCluster: {strings.Repeat("x", 50)},
+	}
+
+	lines := summarizeResults(results, resultLimits{namespaces: 3, messagesPerScope: 2, messageLength: 10})
+	if len(lines) != 4 {
+		t.Fatalf("expected 3 scopes plus an overflow line, got %d: %v", len(lines), lines)
+	}
+
+	if !strings.HasPrefix(lines[0], ResultScopeVelero) || !strings.HasPrefix(lines[1], ResultScopeCluster) {
+		t.Fatalf("expected velero and cluster scopes first, got %v", lines)
+	}
+
+	if len(lines[1]) > len(ResultScopeCluster)+len(" (1): ")+10 {
+		t.Fatalf("expected long message to be truncated, got %q", lines[1])
+	}
+
+	if lines[2] != "alpha (3): a1; a2 (+1 more)" {
+		t.Fatalf("unexpected namespace line %q", lines[2])
+	}
+
+	if lines[3] != "… and 1 more namespaces" {
+		t.Fatalf("unexpected overflow line %q", lines[3])
+	}
+}
@@ -0,0 +1,361 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Route is a node of the routing tree. A route matches an event when every
+// configured matcher matches; empty matchers match everything. Child routes
+// are evaluated in order and the first match wins unless it sets Continue.
+// When no child matches, the event goes to the route's own receivers.
+type Route struct {
+	Receivers []string
+	Match     RouteMatch
+	Continue  bool
+	Routes    []Route
+	// Mentions are pinged by chat receivers for the events of the route.
+	Mentions []string
+}
+
+// RouteMatch lists the conditions of a route. Values support shell-style
+// globs such as "prod-*".
+type RouteMatch struct {
+	Schedules        []string          `json:"schedules,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Namespaces       []string          `json:"namespaces,omitempty"`
+	StorageLocations []string          `json:"storageLocations,omitempty"`
+	// Phases match the notification status or the Velero phase.
+	Phases []string `json:"phases,omitempty"`
+	// VeleroNamespaces match the namespace of the Velero installation.
+	VeleroNamespaces []string `json:"veleroNamespaces,omitempty"`
+	Clusters         []string `json:"clusters,omitempty"`
+}
+
+// Router dispatches events to named receivers following a routing tree.
+type Router struct {
+	mu        sync.RWMutex
+	root      Route
+	receivers map[string]Notifier
+	silencer  *Silencer
+}
+
+// NewRouter validates that every receiver referenced by the tree exists. A
+// root route without receivers sends to every receiver, which keeps the
+// behaviour of a configuration without routes.
+func NewRouter(root Route, receivers map[string]Notifier) (*Router, error) {
+	if len(root.Receivers) == 0 {
+		for name := range receivers {
+			root.Receivers = append(root.Receivers, name)
+		}
+		sort.Strings(root.Receivers)
+	}
+
+	if err := validateRoute(root, receivers); err != nil {
+		return nil, err
+	}
+
+	return &Router{root: root, receivers: receivers}, nil
+}
+
+func validateRoute(route Route, receivers map[string]Notifier) error {
+	for _, name := range route.Receivers {
+		if _, ok := receivers[name]; !ok {
+			return fmt.Errorf("route references unknown receiver %q", name)
+		}
+	}
+
+	for _, pattern := range route.Match.patterns() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid route pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, child := range route.Routes {
+		if err := validateRoute(child, receivers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match returns the names of the receivers the event should be sent to.
+func (r *Router) Match(event Event) []string {
+	targets := r.targets(event)
+	names := make([]string, 0, len(targets))
+	for _, target := range targets {
+		names = append(names, target.receiver)
+	}
+	return names
+}
+
+// routeTarget is a receiver selected for an event together with the mentions
+// of the routes that selected it.
+type routeTarget struct {
+	receiver string
+	mentions []string
+}
+
+// targets walks the routing tree and adds the receivers requested by the
+// event overrides. A receiver reached through several routes gets the
+// mentions of all of them.
+func (r *Router) targets(event Event) []routeTarget {
+	var targets []routeTarget
+	index := make(map[string]int)
+	add := func(target routeTarget) {
+		if i, ok := index[target.receiver]; ok {
+			targets[i].mentions = appendUnique(targets[i].mentions, target.mentions...)
+			return
+		}
+		index[target.receiver] = len(targets)
+		targets = append(targets, target)
+	}
+
+	root, receivers, _ := r.current()
+	for _, target := range matchRoute(root, event, nil) {
+		add(target)
+	}
+
+	for _, name := range event.Overrides.Receivers {
+		if _, ok := receivers[name]; !ok {
+			log.Printf("Ignoring unknown receiver %q requested for %s.", name, overrideTarget(event))
+			continue
+		}
+		add(routeTarget{receiver: name})
+	}
+	return targets
+}
+
+// SetSilencer mutes the events matching its silences and maintenance
+// windows.
+func (r *Router) SetSilencer(silencer *Silencer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.silencer = silencer
+}
+
+// Replace swaps in the routes and receivers of another router, so a reloaded
+// configuration reaches everything holding this router. The silencer is
+// kept.
+func (r *Router) Replace(next *Router) {
+	root, receivers, _ := next.current()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.root, r.receivers = root, receivers
+}
+
+// Receivers returns the receivers of the router by name.
+func (r *Router) Receivers() map[string]Notifier {
+	_, receivers, _ := r.current()
+	return maps.Clone(receivers)
+}
+
+func (r *Router) current() (Route, map[string]Notifier, *Silencer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.root, r.receivers, r.silencer
+}
+
+// Dispatch sends the event to every matching receiver and joins the errors.
+func (r *Router) Dispatch(event Event) error {
+	return r.dispatch(event, r.targets(event))
+}
+
+// DispatchTo sends the event to the named receivers, bypassing the routing
+// tree but not the silences.
+func (r *Router) DispatchTo(event Event, names []string) error {
+	targets := make([]routeTarget, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, routeTarget{receiver: name})
+	}
+	return r.dispatch(event, targets)
+}
+
+func (r *Router) dispatch(event Event, targets []routeTarget) error {
+	if event.Overrides.Mute {
+		log.Printf("Suppressing %s notification: muted by %s.", event.Status, overrideTarget(event))
+		for _, target := range targets {
+			suppressedEvents.WithLabelValues(event.Cluster, target.receiver, SuppressedByMute).Inc()
+		}
+		return nil
+	}
+
+	_, receivers, silencer := r.current()
+	if silencer != nil {
+		if reason := silencer.Silenced(event); reason != "" {
+			log.Printf("Suppressing %s notification: %s.", event.Status, reason)
+			for _, target := range targets {
+				suppressedEvents.WithLabelValues(event.Cluster, target.receiver, SuppressedBySilence).Inc()
+			}
+			return nil
+		}
+	}
+
+	var errs []error
+	for _, target := range targets {
+		name := target.receiver
+		receiver, ok := receivers[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown receiver %q", name))
+			continue
+		}
+		event := event
+		event.Mentions = appendUnique(append([]string(nil), target.mentions...), event.Overrides.Mentions...)
+		if err := receiver.NotifyEvent(event); err != nil {
+			failedEvents.WithLabelValues(event.Cluster, name).Inc()
+			errs = append(errs, fmt.Errorf("receiver %s: %w", name, err))
+			continue
+		}
+		dispatchedEvents.WithLabelValues(event.Cluster, name, event.Status).Inc()
+	}
+	return errors.Join(errs...)
+}
+
+// HasReceiver reports whether a receiver with the given name exists.
+func (r *Router) HasReceiver(name string) bool {
+	_, receivers, _ := r.current()
+	_, ok := receivers[name]
+	return ok
+}
+
+// matchRoute returns the receivers of the deepest matching routes, with the
+// mentions of every route on the way.
+func matchRoute(route Route, event Event, mentions []string) []routeTarget {
+	mentions = appendUnique(append([]string(nil), mentions...), route.Mentions...)
+
+	var targets []routeTarget
+	matchedChild := false
+
+	for _, child := range route.Routes {
+		if !child.Match.matches(event) {
+			continue
+		}
+
+		if len(child.Receivers) == 0 {
+			child.Receivers = route.Receivers
+		}
+
+		targets = append(targets, matchRoute(child, event, mentions)...)
+		matchedChild = true
+		if !child.Continue {
+			break
+		}
+	}
+
+	if !matchedChild {
+		for _, name := range route.Receivers {
+			targets = append(targets, routeTarget{receiver: name, mentions: mentions})
+		}
+	}
+
+	return targets
+}
+
+// appendUnique appends the values that are not in the slice yet.
+func appendUnique(values []string, extra ...string) []string {
+	for _, value := range extra {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (m RouteMatch) matches(event Event) bool {
+	if len(m.Clusters) > 0 && !matchAny(m.Clusters, event.Cluster) {
+		return false
+	}
+
+	if len(m.VeleroNamespaces) > 0 && !matchAny(m.VeleroNamespaces, event.VeleroNamespace) {
+		return false
+	}
+
+	if len(m.Schedules) > 0 && !matchAny(m.Schedules, event.Schedule) {
+		return false
+	}
+
+	if len(m.StorageLocations) > 0 && !matchAny(m.StorageLocations, event.StorageLocation) {
+		return false
+	}
+
+	if len(m.Phases) > 0 && !matchAnyFold(m.Phases, event.Status) && (event.Phase == "" || !matchAnyFold(m.Phases, event.Phase)) {
+		return false
+	}
+
+	for key, pattern := range m.Labels {
+		value, ok := event.Labels[key]
+		if !ok || !matchAny([]string{pattern}, value) {
+			return false
+		}
+	}
+
+	// An event covering "*" matches any namespace pattern; one without
+	// namespaces matches none.
+	if len(m.Namespaces) > 0 {
+		found := false
+		for _, namespace := range event.Namespaces {
+			if namespace == "*" || matchAny(m.Namespaces, namespace) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m RouteMatch) patterns() []string {
+	patterns := make([]string, 0, len(m.Schedules)+len(m.Labels)+len(m.Namespaces)+len(m.StorageLocations)+len(m.VeleroNamespaces)+len(m.Clusters))
+	patterns = append(patterns, m.Schedules...)
+	patterns = append(patterns, m.Clusters...)
+	patterns = append(patterns, m.VeleroNamespaces...)
+	patterns = append(patterns, m.Namespaces...)
+	patterns = append(patterns, m.StorageLocations...)
+	for _, value := range m.Labels {
+		patterns = append(patterns, value)
+	}
+	return patterns
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, value)
+		if err != nil {
+			log.Printf("Invalid route pattern %q: %v", pattern, err)
+			continue
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideTarget names the object whose annotations set the overrides.
+func overrideTarget(event Event) string {
+	if event.BackupName != "" {
+		return "backup " + event.BackupName
+	}
+	return "schedule " + event.Schedule
+}
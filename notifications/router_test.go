@@ -0,0 +1,287 @@
+package notifications
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type recordingNotifier struct {
+	events []Event
+	err    error
+}
+
+func (r *recordingNotifier) Notify(status, message string) error {
+	return r.NotifyEvent(Event{Status: status, Message: message})
+}
+
+func (r *recordingNotifier) NotifyEvent(event Event) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func newTestRouter(t *testing.T, root Route, names ...string) (*Router, map[string]*recordingNotifier) {
+	t.Helper()
+
+	recorders := make(map[string]*recordingNotifier, len(names))
+	receivers := make(map[string]Notifier, len(names))
+	for _, name := range names {
+		recorders[name] = &recordingNotifier{}
+		receivers[name] = recorders[name]
+	}
+
+	router, err := NewRouter(root, receivers)
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+	return router, recorders
+}
+
+func TestRouterWithoutRoutesSendsToEveryReceiver(t *testing.T) {
+	t.Parallel()
+
+	router, _ := newTestRouter(t, Route{}, "slack", "email")
+
+	got := router.Match(Event{Status: "Completed"})
+	if !reflect.DeepEqual(got, []string{"email", "slack"}) {
+		t.Fatalf("expected all receivers, got %v", got)
+	}
+}
+
+func TestRouterMatchesChildRoutes(t *testing.T) {
+	t.Parallel()
+
+	root := Route{
+		Receivers: []string{"default"},
+		Routes: []Route{
+			{
+				Receivers: []string{"team-a"},
+				Match:     RouteMatch{Schedules: []string{"team-a-*"}},
+			},
+			{
+				Receivers: []string{"dba"},
+				Match: RouteMatch{
+					Namespaces: []string{"postgres-*"},
+					Phases:     []string{"failed"},
+				},
+				Continue: true,
+			},
+			{
+				Receivers: []string{"prod"},
+				Match:     RouteMatch{Labels: map[string]string{"env": "prod"}},
+			},
+		},
+	}
+	router, _ := newTestRouter(t, root, "default", "team-a", "dba", "prod")
+
+	cases := []struct {
+		name  string
+		event Event
+		want  []string
+	}{
+		{
+			name:  "schedule match stops evaluation",
+			event: Event{Status: "Failed", Schedule: "team-a-nightly", Namespaces: []string{"postgres-main"}},
+			want:  []string{"team-a"},
+		},
+		{
+			name:  "continue collects following matches",
+			event: Event{Status: "Failed", Namespaces: []string{"postgres-main"}, Labels: map[string]string{"env": "prod"}},
+			want:  []string{"dba", "prod"},
+		},
+		{
+			name:  "backup of every namespace matches namespace patterns",
+			event: Event{Status: "Failed", Namespaces: []string{"*"}},
+			want:  []string{"dba"},
+		},
+		{
+			name:  "event without namespaces skips namespace patterns",
+			event: Event{Status: "Failed"},
+			want:  []string{"default"},
+		},
+		{
+			name:  "phase matches the Velero phase of a refined status",
+			event: Event{Status: "Recovered", Phase: "Failed", Namespaces: []string{"postgres-main"}},
+			want:  []string{"dba"},
+		},
+		{
+			name:  "phase mismatch falls through",
+			event: Event{Status: "Completed", Namespaces: []string{"postgres-main"}},
+			want:  []string{"default"},
+		},
+		{
+			name:  "no match uses default route",
+			event: Event{Status: "Error"},
+			want:  []string{"default"},
+		},
+	}
+
+	for _, tc := range cases {
+		if got := router.Match(tc.event); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRouterChildInheritsParentReceivers(t *testing.T) {
+	t.Parallel()
+
+	root := Route{
+		Receivers: []string{"default"},
+		Routes: []Route{{
+			Match: RouteMatch{StorageLocations: []string{"aws-*"}},
+		}},
+	}
+	router, _ := newTestRouter(t, root, "default", "other")
+
+	if got := router.Match(Event{StorageLocation: "aws-primary"}); !reflect.DeepEqual(got, []string{"default"}) {
+		t.Fatalf("expected inherited receivers, got %v", got)
+	}
+}
+
+func TestNewRouterRejectsUnknownReceivers(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRouter(Route{Routes: []Route{{Receivers: []string{"missing"}}}}, map[string]Notifier{"slack": &recordingNotifier{}})
+	if err == nil {
+		t.Fatal("expected error for unknown receiver")
+	}
+}
+
+func TestRouterDispatchJoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	router, recorders := newTestRouter(t, Route{}, "slack", "email")
+	recorders["slack"].err = errors.New("boom")
+
+	err := router.Dispatch(Event{Status: "Failed"})
+	if err == nil {
+		t.Fatal("expected dispatch error")
+	}
+
+	if len(recorders["email"].events) != 1 {
+		t.Fatal("expected email receiver to still be notified")
+	}
+}
+
+func TestRouterAppliesAnnotationOverrides(t *testing.T) {
+	t.Parallel()
+
+	root := Route{Receivers: []string{"default"}}
+	router, recorders := newTestRouter(t, root, "default", "team-a")
+
+	got := router.Match(Event{Status: "Failed", Overrides: Overrides{Receivers: []string{"team-a", "default", "missing"}}})
+	if !reflect.DeepEqual(got, []string{"default", "team-a"}) {
+		t.Fatalf("expected the annotation receiver to be added, got %v", got)
+	}
+
+	if err := router.Dispatch(Event{Status: "Failed", BackupName: "adhoc", Overrides: Overrides{Mute: true}}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if len(recorders["default"].events) != 0 {
+		t.Fatalf("expected a muted event to be dropped, got %v", recorders["default"].events)
+	}
+}
+
+func TestRouterAddsRouteMentions(t *testing.T) {
+	t.Parallel()
+
+	root := Route{
+		Receivers: []string{"slack"},
+		Routes: []Route{
+			{
+				Match:    RouteMatch{Schedules: []string{"prod-*"}, Phases: []string{"Failed"}},
+				Mentions: []string{"<!subteam^S123>"},
+				Continue: true,
+			},
+			{
+				Receivers: []string{"slack", "email"},
+				Match:     RouteMatch{Schedules: []string{"prod-*"}},
+				Mentions:  []string{"<!here>"},
+			},
+		},
+	}
+	router, recorders := newTestRouter(t, root, "slack", "email")
+
+	event := Event{Status: "Failed", Schedule: "prod-db", Overrides: Overrides{Mentions: []string{"<@U123>"}}}
+	if err := router.Dispatch(event); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if err := router.Dispatch(Event{Status: "Completed", Schedule: "prod-db"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	slack := recorders["slack"].events
+	if len(slack) != 2 {
+		t.Fatalf("expected two slack events, got %v", slack)
+	}
+	if want := []string{"<!subteam^S123>", "<!here>", "<@U123>"}; !reflect.DeepEqual(slack[0].Mentions, want) {
+		t.Fatalf("expected mentions %v for the failure, got %v", want, slack[0].Mentions)
+	}
+	if want := []string{"<!here>"}; !reflect.DeepEqual(slack[1].Mentions, want) {
+		t.Fatalf("expected mentions %v for the completion, got %v", want, slack[1].Mentions)
+	}
+	if want := []string{"<!here>", "<@U123>"}; !reflect.DeepEqual(recorders["email"].events[0].Mentions, want) {
+		t.Fatalf("expected email to get only its route mentions, got %v", recorders["email"].events[0].Mentions)
+	}
+}
+
+func TestRouterMatchesVeleroNamespaces(t *testing.T) {
+	t.Parallel()
+
+	root := Route{
+		Receivers: []string{"default"},
+		Routes: []Route{
+			{Receivers: []string{"dr"}, Match: RouteMatch{VeleroNamespaces: []string{"velero-dr"}}},
+		},
+	}
+	router, _ := newTestRouter(t, root, "default", "dr")
+
+	if got := router.Match(Event{Status: "Failed", VeleroNamespace: "velero-dr"}); !reflect.DeepEqual(got, []string{"dr"}) {
+		t.Fatalf("expected the dr receiver, got %v", got)
+	}
+	if got := router.Match(Event{Status: "Failed", VeleroNamespace: "velero"}); !reflect.DeepEqual(got, []string{"default"}) {
+		t.Fatalf("expected the default receiver, got %v", got)
+	}
+}
+
+func TestRouterCountsEventsPerCluster(t *testing.T) {
+	t.Parallel()
+
+	router, _ := newTestRouter(t, Route{}, "metrics-test")
+
+	before := testutil.ToFloat64(dispatchedEvents.WithLabelValues("prod-eu", "metrics-test", "Failed"))
+	if err := router.Dispatch(Event{Status: "Failed", Cluster: "prod-eu"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if got := testutil.ToFloat64(dispatchedEvents.WithLabelValues("prod-eu", "metrics-test", "Failed")); got != before+1 {
+		t.Fatalf("expected the dispatch to be counted for the cluster, got %v", got-before)
+	}
+}
+
+func TestRouterReplaceKeepsSilencer(t *testing.T) {
+	t.Parallel()
+
+	router, old := newTestRouter(t, Route{}, "old")
+	silencer := NewSilencer(nil)
+	router.SetSilencer(silencer)
+
+	next, recorders := newTestRouter(t, Route{}, "new")
+	router.Replace(next)
+
+	if router.HasReceiver("old") || !router.HasReceiver("new") {
+		t.Fatal("expected the receivers to be replaced")
+	}
+	if err := router.Dispatch(Event{Status: "Failed"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if len(old["old"].events) != 0 || len(recorders["new"].events) != 1 {
+		t.Fatal("expected the event to reach the new receiver only")
+	}
+	if _, _, current := router.current(); current != silencer {
+		t.Fatal("expected the silencer to be kept")
+	}
+}
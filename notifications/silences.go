@@ -0,0 +1,198 @@
+package notifications
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Sources of silences, used to replace every silence of a source at once.
+const (
+	SilenceSourceAPI        = "api"
+	SilenceSourceConfigMap  = "configmap"
+	SilenceSourceAnnotation = "annotation"
+)
+
+// Silence mutes the events matching its matchers between StartsAt and
+// EndsAt. A zero StartsAt means the silence is active immediately.
+type Silence struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	// Scope names the cluster and namespace a synced silence was read from.
+	Scope    string     `json:"scope,omitempty"`
+	Match    RouteMatch `json:"match"`
+	StartsAt time.Time  `json:"startsAt"`
+	EndsAt   time.Time  `json:"endsAt"`
+	Comment  string     `json:"comment,omitempty"`
+}
+
+func (s Silence) active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// MaintenanceWindow is a recurring silence that starts on a cron schedule
+// and lasts Duration.
+type MaintenanceWindow struct {
+	Name     string
+	Schedule cron.Schedule
+	Duration time.Duration
+	Match    RouteMatch
+}
+
+// active reports whether a window started within the last Duration.
+func (w MaintenanceWindow) active(now time.Time) bool {
+	start := w.Schedule.Next(now.Add(-w.Duration))
+	return !start.After(now)
+}
+
+// Silencer holds the silences and maintenance windows checked before an
+// event is dispatched.
+type Silencer struct {
+	mu       sync.RWMutex
+	silences map[string]Silence
+	windows  []MaintenanceWindow
+	now      func() time.Time
+}
+
+func NewSilencer(windows []MaintenanceWindow) *Silencer {
+	return &Silencer{
+		silences: make(map[string]Silence),
+		windows:  windows,
+		now:      time.Now,
+	}
+}
+
+// SetWindows replaces the maintenance windows.
+func (s *Silencer) SetWindows(windows []MaintenanceWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows = windows
+}
+
+// Silenced returns the reason the event is muted, or an empty string.
+func (s *Silencer) Silenced(event Event) string {
+	now := s.now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, window := range s.windows {
+		if window.active(now) && window.Match.matches(event) {
+			return "maintenance window " + window.Name
+		}
+	}
+
+	for _, silence := range s.silences {
+		if silence.active(now) && silence.Match.matches(event) {
+			return fmt.Sprintf("silence %s (%s)", silence.ID, silence.Source)
+		}
+	}
+
+	return ""
+}
+
+// Add stores a silence and returns it with its ID.
+func (s *Silencer) Add(silence Silence) (Silence, error) {
+	if err := ValidateSilence(silence); err != nil {
+		return Silence{}, err
+	}
+
+	if silence.ID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return Silence{}, fmt.Errorf("generate silence id: %w", err)
+		}
+		silence.ID = hex.EncodeToString(id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[silence.ID] = silence
+	s.expire()
+	return silence, nil
+}
+
+// Delete removes a silence and reports whether it existed.
+func (s *Silencer) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.silences[id]
+	delete(s.silences, id)
+	return ok
+}
+
+// Replace swaps every silence of a source and scope, which is how each
+// controller syncs the silences it reads from its cluster and namespace.
+// IDs are made unique by appending the scope.
+func (s *Silencer) Replace(source, scope string, silences []Silence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, silence := range s.silences {
+		if silence.Source == source && silence.Scope == scope {
+			delete(s.silences, id)
+		}
+	}
+
+	for i, silence := range silences {
+		silence.Source = source
+		silence.Scope = scope
+		if silence.ID == "" {
+			silence.ID = fmt.Sprintf("%s-%d", source, i)
+		}
+		if scope != "" {
+			silence.ID += "@" + scope
+		}
+		s.silences[silence.ID] = silence
+	}
+	s.expire()
+}
+
+// List returns the silences that have not ended, soonest end first.
+func (s *Silencer) List() []Silence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	silences := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		if now.Before(silence.EndsAt) {
+			silences = append(silences, silence)
+		}
+	}
+	sort.Slice(silences, func(i, j int) bool {
+		return silences[i].EndsAt.Before(silences[j].EndsAt)
+	})
+	return silences
+}
+
+// expire drops the silences that have ended. Callers hold the write lock.
+func (s *Silencer) expire() {
+	now := s.now()
+	for id, silence := range s.silences {
+		if !now.Before(silence.EndsAt) {
+			delete(s.silences, id)
+		}
+	}
+}
+
+// ValidateSilence checks that a silence ends after it starts, has at least
+// one matcher and only valid patterns.
+func ValidateSilence(silence Silence) error {
+	if silence.EndsAt.IsZero() {
+		return fmt.Errorf("silence needs an end time")
+	}
+	if !silence.StartsAt.IsZero() && !silence.StartsAt.Before(silence.EndsAt) {
+		return fmt.Errorf("silence must start before it ends")
+	}
+	if len(silence.Match.patterns()) == 0 && len(silence.Match.Phases) == 0 {
+		return fmt.Errorf("silence needs at least one matcher")
+	}
+	return validateRoute(Route{Match: silence.Match}, nil)
+}
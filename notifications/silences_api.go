@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Handler serves the silences API to the requests that carry token as an
+// "Authorization: Bearer" header:
+//
+//	GET    /api/v1/silences       lists the silences that have not ended
+//	POST   /api/v1/silences       creates a silence from a JSON body
+//	DELETE /api/v1/silences/{id}  removes a silence created through the API
+func (s *Silencer) Handler(token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/v1/silences", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.List())
+	})
+
+	mux.HandleFunc("POST /api/v1/silences", func(w http.ResponseWriter, r *http.Request) {
+		var silence Silence
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&silence); err != nil {
+			http.Error(w, "invalid silence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		silence.ID = ""
+		silence.Source = SilenceSourceAPI
+		created, err := s.Add(silence)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	})
+
+	mux.HandleFunc("DELETE /api/v1/silences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		for _, silence := range s.List() {
+			if silence.ID != id {
+				continue
+			}
+			if silence.Source != SilenceSourceAPI {
+				http.Error(w, "silence is managed by its "+silence.Source, http.StatusConflict)
+				return
+			}
+			s.Delete(id)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Error(w, "silence not found", http.StatusNotFound)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorized reports whether r carries token. An empty token authorizes
+// nothing.
+func authorized(r *http.Request, token string) bool {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,161 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestSilencerMatchesWindowsAndSilences(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC)
+	schedule, err := cron.ParseStandard("0 22 * * *")
+	if err != nil {
+		t.Fatalf("parse schedule: %v", err)
+	}
+
+	silencer := NewSilencer([]MaintenanceWindow{{
+		Name:     "migration",
+		Schedule: schedule,
+		Duration: 2 * time.Hour,
+		Match:    RouteMatch{Schedules: []string{"nightly-*"}},
+	}})
+	silencer.now = func() time.Time { return now }
+
+	if reason := silencer.Silenced(Event{Status: "Failed", Schedule: "nightly-db"}); reason != "maintenance window migration" {
+		t.Fatalf("expected the window to mute the event, got %q", reason)
+	}
+	if reason := silencer.Silenced(Event{Status: "Failed", Schedule: "hourly"}); reason != "" {
+		t.Fatalf("expected other schedules to pass, got %q", reason)
+	}
+
+	now = time.Date(2024, 6, 2, 1, 0, 0, 0, time.UTC)
+	if reason := silencer.Silenced(Event{Status: "Failed", Schedule: "nightly-db"}); reason != "" {
+		t.Fatalf("expected the window to be over, got %q", reason)
+	}
+
+	silencer.Replace(SilenceSourceConfigMap, "", []Silence{{
+		Match:  RouteMatch{Schedules: []string{"hourly"}},
+		EndsAt: now.Add(time.Hour),
+	}})
+	if reason := silencer.Silenced(Event{Status: "Failed", Schedule: "hourly"}); reason != "silence configmap-0 (configmap)" {
+		t.Fatalf("expected the silence to mute the event, got %q", reason)
+	}
+
+	now = now.Add(time.Hour)
+	if reason := silencer.Silenced(Event{Status: "Failed", Schedule: "hourly"}); reason != "" {
+		t.Fatalf("expected the silence to have ended, got %q", reason)
+	}
+	if silences := silencer.List(); len(silences) != 0 {
+		t.Fatalf("expected ended silences to be hidden, got %v", silences)
+	}
+}
+
+func TestRouterSkipsSilencedEvents(t *testing.T) {
+	t.Parallel()
+
+	router, recorders := newTestRouter(t, Route{}, "slack")
+	recorder := recorders["slack"]
+
+	silencer := NewSilencer(nil)
+	router.SetSilencer(silencer)
+	if _, err := silencer.Add(Silence{Match: RouteMatch{Phases: []string{"PartiallyFailed"}}, EndsAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("add silence: %v", err)
+	}
+
+	if err := router.Dispatch(Event{Status: "PartiallyFailed"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if err := router.Dispatch(Event{Status: "Failed"}); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	if len(recorder.events) != 1 || recorder.events[0].Status != "Failed" {
+		t.Fatalf("expected only the unsilenced event, got %v", recorder.events)
+	}
+}
+
+func TestSilencesAPI(t *testing.T) {
+	t.Parallel()
+
+	silencer := NewSilencer(nil)
+	silencer.Replace(SilenceSourceAnnotation, "", []Silence{{ID: "schedule-nightly", EndsAt: time.Now().Add(time.Hour)}})
+	server := httptest.NewServer(silencer.Handler("s3cret"))
+	defer server.Close()
+
+	request := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return resp
+	}
+
+	endsAt := time.Now().Add(time.Hour).Format(time.RFC3339)
+	body := `{"match":{"schedules":["nightly"]},"endsAt":"` + endsAt + `","comment":"storage upgrade"}`
+	for _, token := range []string{"", "wrong"} {
+		resp := request(http.MethodPost, "/api/v1/silences", token, body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected a request with token %q to be refused, got %d", token, resp.StatusCode)
+		}
+	}
+
+	resp := request(http.MethodPost, "/api/v1/silences", "s3cret", body)
+	var created Silence
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("decode silence: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.ID == "" || created.Source != SilenceSourceAPI {
+		t.Fatalf("unexpected response %d %+v", resp.StatusCode, created)
+	}
+
+	for name, invalid := range map[string]string{
+		"no end":     `{"match":{"schedules":["nightly"]},"comment":"no end"}`,
+		"no matcher": `{"endsAt":"` + endsAt + `","comment":"everything"}`,
+	} {
+		resp := request(http.MethodPost, "/api/v1/silences", "s3cret", invalid)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected a silence with %s to be rejected, got %d", name, resp.StatusCode)
+		}
+	}
+
+	resp = request(http.MethodGet, "/api/v1/silences", "s3cret", "")
+	var listed []Silence
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("decode silences: %v", err)
+	}
+	resp.Body.Close()
+	if len(listed) != 2 {
+		t.Fatalf("expected two silences, got %+v", listed)
+	}
+
+	for id, want := range map[string]int{
+		"schedule-nightly": http.StatusConflict,
+		created.ID:         http.StatusNoContent,
+		"missing":          http.StatusNotFound,
+	} {
+		resp := request(http.MethodDelete, "/api/v1/silences/"+id, "s3cret", "")
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("delete %s: expected %d, got %d", id, want, resp.StatusCode)
+		}
+	}
+
+	if silences := silencer.List(); len(silences) != 1 || silences[0].ID != "schedule-nightly" {
+		t.Fatalf("expected only the annotation silence to remain, got %+v", silences)
+	}
+}
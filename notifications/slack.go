@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type SlackAttachment struct {
@@ -64,9 +65,24 @@ type backupMessageDetails struct {
 	endTime       string
 	progress      string
 	failureReason string
+	// extra holds the remaining lines of the message, rendered as fields
+	// when they look like "Key: Value".
+	extra []string
 }
 
-const slackRequestTimeout = 10 * time.Second
+const (
+	slackRequestTimeout = 10 * time.Second
+	// slackSectionTextLimit is the maximum length Slack accepts for the text
+	// of a section block.
+	slackSectionTextLimit = 3000
+)
+
+var (
+	slackResultLimits = resultLimits{namespaces: 10, messagesPerScope: 3, messageLength: 200}
+	slackVolumeLimit  = 15
+	// slackDigestFailureLength keeps digest rows narrow enough to read.
+	slackDigestFailureLength = 60
+)
 
 var statusMap = map[string]backupStateInfo{
 	"failed": {
@@ -99,6 +115,114 @@ var statusMap = map[string]backupStateInfo{
 		emoji:       ":hourglass_flowing_sand:",
 		headerIcon:  "⏳",
 	},
+	"missed": {
+		displayName: "Missed",
+		color:       "#8B0000",
+		emoji:       ":alarm_clock:",
+		headerIcon:  "⏰",
+	},
+	"failedvalidation": {
+		displayName: "Failed Validation",
+		color:       "#8B0000",
+		emoji:       ":x:",
+		headerIcon:  "🚨",
+	},
+	"stuck": {
+		displayName: "Stuck",
+		color:       "#FFA500",
+		emoji:       ":hourglass:",
+		headerIcon:  "⌛",
+	},
+	"anomaly": {
+		displayName: "Anomaly",
+		color:       "#FFA500",
+		emoji:       ":chart_with_downwards_trend:",
+		headerIcon:  "📉",
+	},
+	"unavailable": {
+		displayName: "Unavailable",
+		color:       "#8B0000",
+		emoji:       ":no_entry:",
+		headerIcon:  "⛔",
+	},
+	"incompletesnapshots": {
+		displayName: "Incomplete Snapshots",
+		color:       "#FF8C00",
+		emoji:       ":camera_with_flash:",
+		headerIcon:  "📸",
+	},
+	"deleting": {
+		displayName: "Deleting",
+		color:       "#808080",
+		emoji:       ":wastebasket:",
+		headerIcon:  "🗑️",
+	},
+	"deleted": {
+		displayName: "Deleted",
+		color:       "#808080",
+		emoji:       ":wastebasket:",
+		headerIcon:  "🗑️",
+	},
+	"deletionfailed": {
+		displayName: "Deletion Failed",
+		color:       "#8B0000",
+		emoji:       ":x:",
+		headerIcon:  "🚨",
+	},
+	"expiring": {
+		displayName: "Expiring",
+		color:       "#FFA500",
+		emoji:       ":hourglass_flowing_sand:",
+		headerIcon:  "⏳",
+	},
+	"recovered": {
+		displayName: "Recovered",
+		color:       "#36A64F",
+		emoji:       ":white_check_mark:",
+		headerIcon:  "💚",
+	},
+	"digest": {
+		displayName: "Digest",
+		color:       "#439FE0",
+		emoji:       ":bar_chart:",
+		headerIcon:  "📊",
+	},
+	"contactlost": {
+		displayName: "Contact Lost",
+		color:       "#8B0000",
+		emoji:       ":electric_plug:",
+		headerIcon:  "🔌",
+	},
+	"contactrestored": {
+		displayName: "Contact Restored",
+		color:       "#36A64F",
+		emoji:       ":white_check_mark:",
+		headerIcon:  "✅",
+	},
+	"reloadfailed": {
+		displayName: "Reload Failed",
+		color:       "#FFA500",
+		emoji:       ":warning:",
+		headerIcon:  "⚠️",
+	},
+	"available": {
+		displayName: "Available",
+		color:       "#36A64F",
+		emoji:       ":white_check_mark:",
+		headerIcon:  "✅",
+	},
+	"paused": {
+		displayName: "Paused",
+		color:       "#FFA500",
+		emoji:       ":double_vertical_bar:",
+		headerIcon:  "⏸️",
+	},
+	"unpaused": {
+		displayName: "Unpaused",
+		color:       "#36A64F",
+		emoji:       ":arrow_forward:",
+		headerIcon:  "▶️",
+	},
 	"unknown": {
 		displayName: "Unknown",
 		color:       "#FF0000",
@@ -132,17 +256,17 @@ func NewSlackNotifier(cfg SlackConfig) (*SlackNotifier, error) {
 }
 
 func (s *SlackNotifier) Notify(status, message string) error {
+	return s.NotifyEvent(Event{Status: status, Message: message})
+}
+
+func (s *SlackNotifier) NotifyEvent(event Event) error {
+	status, message := event.Status, event.Message
 	finalMessage := strings.TrimSpace(strings.TrimSpace(s.config.Prefix) + " " + strings.TrimSpace(message))
 	backupStatus := inferBackupStatus(status, message)
 
-	// If FailuresOnly is enabled, only proceed for failure states
-	if s.config.FailuresOnly {
-		switch backupStatus {
-		case "failed", "partiallyfailed", "finalizingpartiallyfailed", "unknown", "finalizing":
-
-		default:
-			return nil
-		}
+	// If FailuresOnly is enabled, only proceed for failure and recovery states
+	if (s.config.FailuresOnly || event.Overrides.FailuresOnly) && failuresOnlyDrops(event, backupStatus) {
+		return nil
 	}
 
 	statusInfo := lookupStateInfo(backupStatus)
@@ -150,11 +274,11 @@ func (s *SlackNotifier) Notify(status, message string) error {
 	attachment := SlackAttachment{
 		Fallback: finalMessage,
 		Color:    statusInfo.color,
-		Blocks:   buildBlocks(finalMessage, statusInfo, ts, s.config.Prefix),
+		Blocks:   buildBlocks(finalMessage, statusInfo, ts, s.config.Prefix, event),
 	}
 
 	payload := slackPayload{
-		Text:        fmt.Sprintf("%s Velero Backup Report - %s", statusInfo.headerIcon, statusInfo.displayName),
+		Text:        slackMentions(event.Mentions) + fmt.Sprintf("%s Velero Backup Report - %s", statusInfo.headerIcon, statusInfo.displayName),
 		Channel:     s.config.Channel,
 		Username:    s.config.Username,
 		Attachments: []SlackAttachment{attachment},
@@ -226,24 +350,25 @@ func inferBackupStatus(status, message string) string {
 	}
 }
 
-func normalizeStatus(status string) string {
-	status = strings.TrimSpace(strings.ToLower(status))
-	status = strings.ReplaceAll(status, " ", "")
-	status = strings.ReplaceAll(status, "_", "")
-	status = strings.ReplaceAll(status, "-", "")
-	return status
-}
-
-func buildBlocks(finalMessage string, statusInfo backupStateInfo, ts int64, clusterPrefix string) []SlackBlock {
+func buildBlocks(finalMessage string, statusInfo backupStateInfo, ts int64, clusterPrefix string, event Event) []SlackBlock {
 	details := parseBackupMessageDetails(finalMessage, statusInfo.displayName, clusterPrefix)
 	tsString := strconv.FormatInt(ts, 10)
 
+	clusterName := details.cluster
+	if event.Cluster != "" {
+		clusterName = event.Cluster
+	}
+	cluster := "*Cluster:* " + escapeMrkdwn(clusterName)
+	if event.VeleroNamespace != "" {
+		cluster += "\n*Velero Namespace:* " + escapeMrkdwn(event.VeleroNamespace)
+	}
+
 	blocks := []SlackBlock{
 		{
 			Type: "section",
 			Text: &SlackTextObject{
 				Type: "mrkdwn",
-				Text: "*Cluster:* " + escapeMrkdwn(details.cluster),
+				Text: cluster,
 			},
 		},
 		{
@@ -291,6 +416,62 @@ func buildBlocks(finalMessage string, statusInfo backupStateInfo, ts int64, clus
 		})
 	}
 
+	blocks = append(blocks, extraBlocks(details.extra)...)
+
+	if event.Warnings > 0 || event.Errors > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type: "section",
+			Fields: []SlackTextObject{
+				{
+					Type: "mrkdwn",
+					Text: "*Warnings:*\n" + strconv.Itoa(event.Warnings),
+				},
+				{
+					Type: "mrkdwn",
+					Text: "*Errors:*\n" + strconv.Itoa(event.Errors),
+				},
+			},
+		})
+	}
+
+	if event.Digest != nil && len(event.Digest.Schedules) > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type: "section",
+			Text: &SlackTextObject{
+				Type: "mrkdwn",
+				Text: "```" + truncateText(escapeMrkdwn(digestTable(event.Digest, slackDigestFailureLength)), slackSectionTextLimit-6) + "```",
+			},
+		})
+	}
+
+	if event.Results != nil {
+		blocks = append(blocks, resultBlocks("Errors by namespace", event.Results.Errors)...)
+		blocks = append(blocks, resultBlocks("Warnings by namespace", event.Results.Warnings)...)
+	}
+
+	if lines := summarizeVolumes(event.Volumes, slackVolumeLimit); len(lines) > 0 {
+		for i, line := range lines {
+			lines[i] = "• " + escapeMrkdwn(line)
+		}
+		blocks = append(blocks, SlackBlock{
+			Type: "section",
+			Text: &SlackTextObject{
+				Type: "mrkdwn",
+				Text: truncateText("*Volumes:*\n"+strings.Join(lines, "\n"), slackSectionTextLimit),
+			},
+		})
+	}
+
+	if len(event.LogExcerpt) > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type: "section",
+			Text: &SlackTextObject{
+				Type: "mrkdwn",
+				Text: "*Log Errors:*\n```" + truncateText(escapeMrkdwn(strings.Join(event.LogExcerpt, "\n")), slackSectionTextLimit-32) + "```",
+			},
+		})
+	}
+
 	blocks = append(blocks,
 		SlackBlock{Type: "divider"},
 		SlackBlock{
@@ -347,26 +528,23 @@ func parseBackupMessageDetails(finalMessage, fallbackStatus, clusterPrefix strin
 		details.summaryHeader = strings.TrimSpace(strings.TrimSuffix(summaryLine, "."))
 		details.statusValue = statusMap["completed"].emoji + " Completed."
 	} else {
-		details.summaryHeader = "Backup status"
+		details.summaryHeader = strings.TrimSpace(strings.TrimSuffix(summaryLine, "."))
 		details.statusValue = fallbackStatus + "."
 	}
 
-	for _, line := range cleanLines {
-		if strings.HasPrefix(line, "Start Time:") {
+	for _, line := range cleanLines[1:] {
+		switch {
+		case strings.HasPrefix(line, "Start Time:") && strings.Contains(line, ", End Time:"):
 			raw := strings.TrimSpace(strings.TrimPrefix(line, "Start Time:"))
-			startRaw, endRaw, found := strings.Cut(raw, ", End Time:")
-			if found {
-				details.startTime = normalizeTimeDisplay(strings.TrimSpace(startRaw))
-				details.endTime = normalizeTimeDisplay(strings.TrimSpace(strings.TrimSuffix(endRaw, ".")))
-			}
-		}
-
-		if strings.HasPrefix(line, "Progress:") {
+			startRaw, endRaw, _ := strings.Cut(raw, ", End Time:")
+			details.startTime = normalizeTimeDisplay(strings.TrimSpace(startRaw))
+			details.endTime = normalizeTimeDisplay(strings.TrimSpace(strings.TrimSuffix(endRaw, ".")))
+		case strings.HasPrefix(line, "Progress:"):
 			details.progress = strings.TrimSpace(strings.TrimPrefix(line, "Progress:"))
-		}
-
-		if strings.HasPrefix(line, "Failure Reason:") {
+		case strings.HasPrefix(line, "Failure Reason:"):
 			details.failureReason = strings.TrimSpace(strings.TrimPrefix(line, "Failure Reason:"))
+		default:
+			details.extra = append(details.extra, line)
 		}
 	}
 
@@ -420,3 +598,103 @@ func escapeMrkdwn(input string) string {
 
 	return replacer.Replace(input)
 }
+
+func extraBlocks(lines []string) []SlackBlock {
+	var (
+		blocks []SlackBlock
+		fields []SlackTextObject
+		text   []string
+	)
+
+	for _, line := range lines {
+		key, value, found := strings.Cut(line, ": ")
+		if found && len(key) <= 40 && !strings.ContainsAny(key, ".!?") {
+			fields = append(fields, SlackTextObject{
+				Type: "mrkdwn",
+				Text: "*" + escapeMrkdwn(key) + ":*\n" + escapeMrkdwn(strings.TrimSpace(value)),
+			})
+			continue
+		}
+		text = append(text, escapeMrkdwn(line))
+	}
+
+	// Slack accepts at most 10 fields per section block.
+	for len(fields) > 0 {
+		chunk := fields[:min(len(fields), 10)]
+		fields = fields[len(chunk):]
+		blocks = append(blocks, SlackBlock{Type: "section", Fields: chunk})
+	}
+
+	if len(text) > 0 {
+		blocks = append(blocks, SlackBlock{
+			Type: "section",
+			Text: &SlackTextObject{
+				Type: "mrkdwn",
+				Text: truncateText(strings.Join(text, "\n"), slackSectionTextLimit),
+			},
+		})
+	}
+
+	return blocks
+}
+
+func resultBlocks(title string, results map[string][]string) []SlackBlock {
+	lines := summarizeResults(results, slackResultLimits)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	for i, line := range lines {
+		lines[i] = "• " + escapeMrkdwn(line)
+	}
+
+	return []SlackBlock{{
+		Type: "section",
+		Text: &SlackTextObject{
+			Type: "mrkdwn",
+			Text: truncateText("*"+title+":*\n"+strings.Join(lines, "\n"), slackSectionTextLimit),
+		},
+	}}
+}
+
+func truncateText(input string, limit int) string {
+	if len(input) <= limit {
+		return input
+	}
+
+	cut := limit - len("…")
+	for cut > 0 && !utf8.RuneStart(input[cut]) {
+		cut--
+	}
+
+	return input[:cut] + "…"
+}
+
+// slackMentions renders mentions as Slack mention syntax followed by a space:
+// "here", "channel" and "everyone" become special mentions, other names are
+// taken as user IDs, and values already in "<...>" form are kept as is.
+func slackMentions(mentions []string) string {
+	var rendered []string
+	for _, mention := range mentions {
+		mention = strings.TrimSpace(mention)
+		switch {
+		case mention == "":
+			continue
+		case strings.HasPrefix(mention, "<"):
+			rendered = append(rendered, mention)
+		default:
+			name := strings.TrimPrefix(mention, "@")
+			switch name {
+			case "here", "channel", "everyone":
+				rendered = append(rendered, "<!"+name+">")
+			default:
+				rendered = append(rendered, "<@"+name+">")
+			}
+		}
+	}
+
+	if len(rendered) == 0 {
+		return ""
+	}
+	return strings.Join(rendered, " ") + " "
+}
@@ -140,7 +140,7 @@ func TestSlackNotifierNotifyEscapesMrkdwnContent(t *testing.T) {
 	}
 	notifier.client = server.Client()
 
-	err = notifier.Notify("PartiallyFailed", "Backup velero-homelab-16-20260318172303 finished with status: PartiallyFailed.\n\nStart Time: Wed, Mar 18, 2026 at 5:23 PM UTC, End Time: Wed, Mar 18, 2026 at 5:49 PM UTC.\n\nProgress: 341/341 items processed (with 2 errors).\nFailure Reason: Failed <prod> & needs <@U123>")
+	err = notifier.Notify("PartiallyFailed", "Backup velero-homelab-16-20260318172303 finished with status: PartiallyFailed.\n\nStart Time: Wed, Mar 18, 2026 at 5:23 PM UTC, End Time: Wed, Mar 18, 2026 at 5:49 PM UTC.\n\nProgress: 341/341 items processed\nFailure Reason: Failed <prod> & needs <@U123>")
 	if err != nil {
 		t.Fatalf("notify: %v", err)
 	}
@@ -229,9 +229,194 @@ func TestSlackNotifierNotifyFailuresOnlySkipsCompleted(t *testing.T) {
 		t.Fatalf("notify failed: %v", err)
 	}
 
+	if err := notifier.Notify("Recovered", "Backup nightly-1 of schedule nightly: Recovered after 3 failures (last success 3d ago)."); err != nil {
+		t.Fatalf("notify recovered: %v", err)
+	}
+
+	if err := notifier.NotifyEvent(Event{Status: "Completed", Message: "Backup nightly-1 completed successfully.\nPreviously reported as stuck.", FollowUp: true}); err != nil {
+		t.Fatalf("notify follow-up: %v", err)
+	}
+
+	if err := notifier.Notify("Unavailable", "Backup storage location default is unavailable.\n\nProvider: aws"); err != nil {
+		t.Fatalf("notify unavailable: %v", err)
+	}
+
+	if err := notifier.Notify("Available", "Backup storage location default is available again.\n\nProvider: aws"); err != nil {
+		t.Fatalf("notify available: %v", err)
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
-	if requestCnt != 1 {
-		t.Fatalf("expected only one request for failures_only=true, got %d", requestCnt)
+	if requestCnt != 5 {
+		t.Fatalf("expected the failures, the recoveries and the follow-up for failures_only=true, got %d requests", requestCnt)
+	}
+}
+
+func TestSlackNotifierNotifyEventIncludesLogExcerpt(t *testing.T) {
+	t.Parallel()
+
+	var (
+		captured slackPayload
+		mu       sync.Mutex
+	)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &captured)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier, err := NewSlackNotifier(SlackConfig{Webhook: server.URL})
+	if err != nil {
+		t.Fatalf("new notifier: %v", err)
+	}
+	notifier.client = server.Client()
+
+	err = notifier.NotifyEvent(Event{
+		Status:     "Failed",
+		Message:    "Backup demo finished with status: Failed.",
+		LogExcerpt: []string{`level=error msg="Error backing up item" error="<timeout>"`},
+	})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var found bool
+	for _, block := range captured.Attachments[0].Blocks {
+		if block.Text != nil && strings.HasPrefix(block.Text.Text, "*Log Errors:*") {
+			found = true
+			if !strings.Contains(block.Text.Text, "&lt;timeout&gt;") {
+				t.Fatalf("expected escaped log excerpt, got %q", block.Text.Text)
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("expected a log errors block")
+	}
+}
+
+func TestBuildBlocksRendersWarningsErrorsAndResults(t *testing.T) {
+	t.Parallel()
+
+	message := "Backup demo finished with status: PartiallyFailed.\n\nStart Time: 03/18/26 at 5:23 PM UTC, End Time: 03/18/26 at 5:49 PM UTC.\n\nProgress: 341/341 items processed\nFailure Reason: timed out"
+	blocks := buildBlocks(message, statusMap["partiallyfailed"], 0, "", Event{
+		Warnings: 3,
+		Errors:   2,
+		Results: &BackupResults{
+			Errors: map[string][]string{"shop": {"pod volume backup failed"}},
+		},
+	})
+
+	var progress, counts, results *SlackBlock
+	for i := range blocks {
+		block := &blocks[i]
+		switch {
+		case block.Text != nil && strings.HasPrefix(block.Text.Text, "*Progress:*"):
+			progress = block
+		case len(block.Fields) == 2 && strings.HasPrefix(block.Fields[0].Text, "*Warnings:*"):
+			counts = block
+		case block.Text != nil && strings.HasPrefix(block.Text.Text, "*Errors by namespace:*"):
+			results = block
+		}
+	}
+
+	if progress == nil || progress.Text.Text != "*Progress:*\n341/341 items processed" {
+		t.Fatalf("expected progress without counts, got %+v", progress)
+	}
+
+	if counts == nil || counts.Fields[0].Text != "*Warnings:*\n3" || counts.Fields[1].Text != "*Errors:*\n2" {
+		t.Fatalf("expected warnings/errors fields, got %+v", counts)
+	}
+
+	if results == nil || !strings.Contains(results.Text.Text, "shop (1): pod volume backup failed") {
+		t.Fatalf("expected errors by namespace block, got %+v", results)
+	}
+}
+
+func TestBuildBlocksRendersExtraLinesAsFields(t *testing.T) {
+	t.Parallel()
+
+	message := "Backup storage location default is unavailable.\n\nProvider: aws\nFailure Reason: NoSuchBucket"
+	blocks := buildBlocks(message, statusMap["unavailable"], 0, "[prod]", Event{})
+
+	if got := blocks[1].Text.Text; got != "*Backup storage location default is unavailable*\nUnavailable." {
+		t.Fatalf("expected summary line as header, got %q", got)
+	}
+
+	var found bool
+	for _, block := range blocks {
+		if len(block.Fields) == 1 && block.Fields[0].Text == "*Provider:*\naws" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected provider field in blocks, got %+v", blocks)
+	}
+}
+
+func TestSlackNotifierHonoursEventOverrides(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		texts []string
+	)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload slackPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		mu.Lock()
+		texts = append(texts, payload.Text)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier, err := NewSlackNotifier(SlackConfig{Webhook: server.URL})
+	if err != nil {
+		t.Fatalf("new notifier: %v", err)
+	}
+	notifier.client = server.Client()
+
+	overrides := Overrides{FailuresOnly: true}
+	mentions := []string{"@here", "U123", "<!subteam^S123>"}
+	if err := notifier.NotifyEvent(Event{Status: "Completed", Message: "Backup done", Overrides: overrides, Mentions: mentions}); err != nil {
+		t.Fatalf("notify completed: %v", err)
+	}
+	if err := notifier.NotifyEvent(Event{Status: "Failed", Message: "Backup failed", Overrides: overrides, Mentions: mentions}); err != nil {
+		t.Fatalf("notify failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(texts) != 1 {
+		t.Fatalf("expected only the failure to be sent, got %v", texts)
+	}
+	if !strings.HasPrefix(texts[0], "<!here> <@U123> <!subteam^S123> ") {
+		t.Fatalf("expected mentions in the message text, got %q", texts[0])
+	}
+}
+
+func TestBuildBlocksPrefersEventCluster(t *testing.T) {
+	t.Parallel()
+
+	blocks := buildBlocks("[k8s] Backup demo finished with status: Completed.", statusMap["completed"], 0, "[k8s]", Event{
+		Cluster:         "prod-eu",
+		VeleroNamespace: "velero-dr",
+	})
+
+	if got := blocks[0].Text.Text; got != "*Cluster:* prod-eu\n*Velero Namespace:* velero-dr" {
+		t.Fatalf("expected the event cluster and namespace, got %q", got)
 	}
 }
@@ -0,0 +1,219 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ThrottleOptions limits how many notifications a receiver sends.
+type ThrottleOptions struct {
+	// DedupWindow drops events whose fingerprint was sent within the
+	// window. Zero disables deduplication.
+	DedupWindow time.Duration
+	// RatePerMinute and Burst size the token bucket of the receiver. Zero
+	// disables rate limiting.
+	RatePerMinute float64
+	Burst         int
+}
+
+// ThrottledNotifier wraps a receiver with deduplication and a token bucket.
+// Dropped events are counted per fingerprint and reported in a single
+// follow-up once the dedup window of that fingerprint ends.
+type ThrottledNotifier struct {
+	name    string
+	inner   Notifier
+	options ThrottleOptions
+	limiter *rate.Limiter
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*throttleEntry
+}
+
+type throttleEntry struct {
+	sent       time.Time
+	suppressed int
+	since      time.Time
+	last       Event
+	timer      *time.Timer
+}
+
+var fingerprintDigits = regexp.MustCompile(`[0-9]+`)
+
+// NewThrottledNotifier returns inner unchanged when the options disable both
+// deduplication and rate limiting.
+func NewThrottledNotifier(name string, inner Notifier, options ThrottleOptions) Notifier {
+	if options.DedupWindow <= 0 && options.RatePerMinute <= 0 {
+		return inner
+	}
+
+	throttled := &ThrottledNotifier{
+		name:    name,
+		inner:   inner,
+		options: options,
+		now:     time.Now,
+		entries: make(map[string]*throttleEntry),
+	}
+	if options.RatePerMinute > 0 {
+		throttled.limiter = rate.NewLimiter(rate.Limit(options.RatePerMinute/60), max(options.Burst, 1))
+	}
+	return throttled
+}
+
+func (t *ThrottledNotifier) Notify(status, message string) error {
+	return t.NotifyEvent(Event{Status: status, Message: message})
+}
+
+func (t *ThrottledNotifier) NotifyEvent(event Event) error {
+	key := fingerprint(event)
+	now := t.now()
+
+	t.mu.Lock()
+	t.prune(now)
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &throttleEntry{}
+		t.entries[key] = entry
+	}
+
+	duplicate := t.options.DedupWindow > 0 && !entry.sent.IsZero() && now.Sub(entry.sent) < t.options.DedupWindow
+	limited := !duplicate && t.limiter != nil && !t.limiter.AllowN(now, 1)
+	if duplicate || limited {
+		t.suppress(key, entry, event, now)
+		t.mu.Unlock()
+		if limited {
+			suppressedEvents.WithLabelValues(event.Cluster, t.name, SuppressedByRateLimit).Inc()
+			log.Printf("[%s] Rate limit reached, suppressing %s notification.", t.name, event.Status)
+		} else {
+			suppressedEvents.WithLabelValues(event.Cluster, t.name, SuppressedByDedup).Inc()
+		}
+		return nil
+	}
+
+	event = withSuppressedNote(event, entry)
+	entry.sent = now
+	entry.suppressed = 0
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+	t.mu.Unlock()
+
+	return t.inner.NotifyEvent(event)
+}
+
+// prune forgets the fingerprints that can no longer suppress anything.
+// Callers hold the mutex.
+func (t *ThrottledNotifier) prune(now time.Time) {
+	for key, entry := range t.entries {
+		if entry.suppressed == 0 && now.Sub(entry.sent) >= t.options.DedupWindow {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// suppress counts a dropped event and schedules the follow-up. Callers hold
+// the mutex.
+func (t *ThrottledNotifier) suppress(key string, entry *throttleEntry, event Event, now time.Time) {
+	if entry.suppressed == 0 {
+		entry.since = now
+	}
+	entry.suppressed++
+	entry.last = event
+
+	if entry.timer == nil {
+		wait := t.options.DedupWindow - now.Sub(entry.sent)
+		if entry.sent.IsZero() || wait <= 0 {
+			wait = t.options.DedupWindow
+		}
+		if wait <= 0 {
+			wait = time.Minute
+		}
+		entry.timer = time.AfterFunc(wait, func() { t.flush(key) })
+	}
+}
+
+// Stop cancels the pending follow-ups, for a receiver replaced by a
+// configuration reload. The events they would have reported are dropped.
+func (t *ThrottledNotifier) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	dropped := 0
+	for key, entry := range t.entries {
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		dropped += entry.suppressed
+		delete(t.entries, key)
+	}
+	if dropped > 0 {
+		log.Printf("[%s] Dropping the follow-up for %d suppressed events of the replaced receiver.", t.name, dropped)
+	}
+}
+
+// flush sends the follow-up for the events suppressed under a fingerprint.
+// The follow-up itself goes through the rate limiter and is rescheduled
+// when no token is available.
+func (t *ThrottledNotifier) flush(key string) {
+	now := t.now()
+
+	t.mu.Lock()
+	entry, ok := t.entries[key]
+	if !ok || entry.suppressed == 0 {
+		t.mu.Unlock()
+		return
+	}
+	entry.timer = nil
+
+	if t.limiter != nil && !t.limiter.AllowN(now, 1) {
+		entry.timer = time.AfterFunc(time.Minute, func() { t.flush(key) })
+		t.mu.Unlock()
+		return
+	}
+
+	event := withSuppressedNote(entry.last, entry)
+	entry.sent = now
+	entry.suppressed = 0
+	t.mu.Unlock()
+
+	if err := t.inner.NotifyEvent(event); err != nil {
+		log.Printf("[%s] Error sending suppressed events summary: %v", t.name, err)
+	}
+}
+
+// withSuppressedNote appends the count of suppressed similar events.
+func withSuppressedNote(event Event, entry *throttleEntry) Event {
+	if entry.suppressed == 0 {
+		return event
+	}
+
+	noun := "events"
+	if entry.suppressed == 1 {
+		noun = "event"
+	}
+	event.Message = fmt.Sprintf("%s\nSuppressed: %d similar %s since %s", strings.TrimRight(event.Message, "\n"), entry.suppressed, noun, entry.since.Format(time.RFC3339))
+	return event
+}
+
+// fingerprint identifies similar events: the same status about the same
+// objects of the same cluster and Velero installation with the same summary
+// line, ignoring numbers such as ports and timestamps in error messages.
+func fingerprint(event Event) string {
+	summary, _, _ := strings.Cut(strings.TrimSpace(event.Message), "\n")
+	return strings.Join([]string{
+		strings.ToLower(event.Status),
+		event.Cluster,
+		event.VeleroNamespace,
+		event.BackupName,
+		event.Schedule,
+		event.StorageLocation,
+		fingerprintDigits.ReplaceAllString(summary, "#"),
+	}, "\x00")
+}
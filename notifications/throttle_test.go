@@ -0,0 +1,94 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestThrottle(options ThrottleOptions, now *time.Time) (*ThrottledNotifier, *recordingNotifier) {
+	recorder := &recordingNotifier{}
+	throttled := NewThrottledNotifier("test", recorder, options).(*ThrottledNotifier)
+	throttled.now = func() time.Time { return *now }
+	return throttled, recorder
+}
+
+func TestThrottledNotifierDeduplicatesSimilarEvents(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 18, 8, 0, 0, 0, time.UTC)
+	throttled, recorder := newTestThrottle(ThrottleOptions{DedupWindow: 10 * time.Minute}, &now)
+
+	for i := 0; i < 5; i++ {
+		_ = throttled.Notify("Error", "Failed to retrieving backups from Velero: dial tcp 10.0.0.1:443: connection refused")
+		now = now.Add(5 * time.Second)
+	}
+	_ = throttled.Notify("Failed", "Backup nightly finished with status: Failed.")
+
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected the first error and the unrelated failure, got %d events", len(recorder.events))
+	}
+
+	now = now.Add(10 * time.Minute)
+	throttled.flush(fingerprint(Event{Status: "Error", Message: "Failed to retrieving backups from Velero: dial tcp 10.0.0.1:443: connection refused"}))
+
+	if len(recorder.events) != 3 {
+		t.Fatalf("expected a follow-up after the window, got %d events", len(recorder.events))
+	}
+	if message := recorder.events[2].Message; !strings.Contains(message, "Suppressed: 4 similar events since 2026-03-18T08:00:05Z") {
+		t.Fatalf("unexpected follow-up %q", message)
+	}
+
+	throttled.flush(fingerprint(recorder.events[2]))
+	if len(recorder.events) != 3 {
+		t.Fatalf("expected nothing left to flush, got %d events", len(recorder.events))
+	}
+}
+
+func TestThrottledNotifierStopDropsFollowUps(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 18, 8, 0, 0, 0, time.UTC)
+	throttled, recorder := newTestThrottle(ThrottleOptions{DedupWindow: 10 * time.Minute}, &now)
+
+	event := Event{Status: "Failed", BackupName: "nightly", Message: "Backup nightly failed."}
+	_ = throttled.NotifyEvent(event)
+	_ = throttled.NotifyEvent(event)
+
+	throttled.Stop()
+	now = now.Add(10 * time.Minute)
+	throttled.flush(fingerprint(event))
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected no follow-up from a stopped receiver, got %d events", len(recorder.events))
+	}
+}
+
+func TestThrottledNotifierRateLimitsPerReceiver(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 18, 8, 0, 0, 0, time.UTC)
+	throttled, recorder := newTestThrottle(ThrottleOptions{RatePerMinute: 1, Burst: 2}, &now)
+
+	for _, backup := range []string{"a", "b", "c"} {
+		_ = throttled.NotifyEvent(Event{Status: "Failed", BackupName: backup, Message: "Backup " + backup + " failed."})
+	}
+	if len(recorder.events) != 2 {
+		t.Fatalf("expected the burst to pass and the third event to be dropped, got %d events", len(recorder.events))
+	}
+
+	now = now.Add(time.Minute)
+	throttled.flush(fingerprint(Event{Status: "Failed", BackupName: "c", Message: "Backup c failed."}))
+	if len(recorder.events) != 3 || !strings.Contains(recorder.events[2].Message, "Suppressed: 1 similar event") {
+		t.Fatalf("expected the dropped event to be reported once a token is available, got %+v", recorder.events)
+	}
+}
+
+func TestNewThrottledNotifierDisabled(t *testing.T) {
+	t.Parallel()
+
+	recorder := &recordingNotifier{}
+	if notifier := NewThrottledNotifier("test", recorder, ThrottleOptions{}); notifier != recorder {
+		t.Fatalf("expected the receiver to be returned unchanged")
+	}
+}
@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VolumeStatus is the outcome of backing up one volume, whether through a
+// native or CSI snapshot, the data mover or a file-system backup.
+type VolumeStatus struct {
+	Namespace string
+	PVC       string
+	// Method is how the volume was backed up, e.g. CSISnapshot.
+	Method     string
+	Phase      string
+	BytesDone  int64
+	BytesTotal int64
+	Message    string
+}
+
+// summarizeVolumes renders one line per volume, failed volumes first, and
+// truncates the list to limit lines.
+func summarizeVolumes(volumes []VolumeStatus, limit int) []string {
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	sorted := append([]VolumeStatus(nil), volumes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		fi, fj := isFailedVolume(sorted[i]), isFailedVolume(sorted[j])
+		if fi != fj {
+			return fi
+		}
+		return sorted[i].Namespace+"/"+sorted[i].PVC < sorted[j].Namespace+"/"+sorted[j].PVC
+	})
+
+	var lines []string
+	for i, volume := range sorted {
+		if limit > 0 && i >= limit {
+			lines = append(lines, fmt.Sprintf("… and %d more volumes", len(sorted)-i))
+			break
+		}
+
+		name := volume.PVC
+		if volume.Namespace != "" {
+			name = volume.Namespace + "/" + name
+		}
+
+		line := fmt.Sprintf("%s (%s): %s", name, volume.Method, volume.Phase)
+		if volume.BytesTotal > 0 {
+			line += fmt.Sprintf(", %s/%s", FormatBytes(volume.BytesDone), FormatBytes(volume.BytesTotal))
+		}
+		if volume.Message != "" {
+			line += " - " + volume.Message
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func isFailedVolume(volume VolumeStatus) bool {
+	phase := strings.ToLower(volume.Phase)
+	return strings.Contains(phase, "fail") || phase == "canceled"
+}
@@ -0,0 +1,27 @@
+package notifications
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeVolumesListsFailuresFirst(t *testing.T) {
+	t.Parallel()
+
+	volumes := []VolumeStatus{
+		{Namespace: "shop", PVC: "data", Method: "CSISnapshot", Phase: "succeeded"},
+		{Namespace: "db", PVC: "pg-0", Method: "PodVolumeBackup", Phase: "Failed", BytesDone: 512 * 1024 * 1024, BytesTotal: 2 * 1024 * 1024 * 1024, Message: "node lost"},
+		{Namespace: "app", PVC: "cache", Method: "NativeSnapshot", Phase: "Completed"},
+	}
+
+	got := summarizeVolumes(volumes, 2)
+	want := []string{
+		"db/pg-0 (PodVolumeBackup): Failed, 512.0 MiB/2.0 GiB - node lost",
+		"app/cache (NativeSnapshot): Completed",
+		"… and 1 more volumes",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected summary:\n got %q\nwant %q", got, want)
+	}
+}